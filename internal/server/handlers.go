@@ -1,8 +1,11 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
 	"page-zen/internal/utils"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,21 +14,166 @@ import (
 type ArticleRequest struct {
 	URL             string `json:"url" binding:"required"`
 	IncludeMarkdown bool   `json:"include_markdown,omitempty"`
+	// ImageFormats lists preferred image formats, e.g. ["avif", "webp", "jpeg"]
+	ImageFormats []string `json:"image_formats,omitempty"`
+	// TrackingParams adds query parameter rules to strip from links, e.g. ["ref", "src_*"]
+	TrackingParams []string `json:"tracking_params,omitempty"`
+	RewriteLinks   bool     `json:"rewrite_links,omitempty"`
+	// IncludeTOC prepends a table of contents to the markdown
+	IncludeTOC bool `json:"include_toc,omitempty"`
+	// WordsPerMinute overrides the reading speed used for the reading time estimate
+	WordsPerMinute int `json:"words_per_minute,omitempty"`
+	// SummarySentences requests an extractive summary of that many sentences
+	SummarySentences int `json:"summary_sentences,omitempty"`
+	// ExcerptMode is "lead" (default) or "smart"
+	ExcerptMode string `json:"excerpt_mode,omitempty"`
+	// ExcerptLength is the maximum excerpt length in characters (default 200)
+	ExcerptLength int `json:"excerpt_length,omitempty"`
+	// KeywordCount requests that many ranked keywords
+	KeywordCount int `json:"keyword_count,omitempty"`
+	// KeywordLanguage overrides the detected language for keyword stopwords, e.g. "de"
+	KeywordLanguage string `json:"keyword_language,omitempty"`
+	// KeywordStopwords are extra words never returned as keywords
+	KeywordStopwords []string `json:"keyword_stopwords,omitempty"`
+	// MarkdownFlavor is "gfm" (default) or "commonmark"
+	MarkdownFlavor string `json:"markdown_flavor,omitempty"`
+	// HeadingStyle is "atx" (default) or "setext"
+	HeadingStyle string `json:"heading_style,omitempty"`
+	// BulletMarker is "-" (default), "*" or "+"
+	BulletMarker string `json:"bullet_marker,omitempty"`
+	// LinkStyle is "inlined" (default) or "referenced"
+	LinkStyle string `json:"link_style,omitempty"`
+	// DropImages leaves images out of the markdown
+	DropImages bool `json:"drop_images,omitempty"`
+	// MaxHeadingLevel renders deeper headings at this level
+	MaxHeadingLevel int `json:"max_heading_level,omitempty"`
+	// WrapWidth hard-wraps markdown paragraphs at this many characters
+	WrapWidth int `json:"wrap_width,omitempty"`
+	// Chunks splits the markdown into chunks for retrieval
+	Chunks bool `json:"chunks,omitempty"`
+	// ChunkSize, ChunkOverlap and ChunkUnit override the default chunking settings
+	ChunkSize    int    `json:"chunk_size,omitempty"`
+	ChunkOverlap *int   `json:"chunk_overlap,omitempty"`
+	ChunkUnit    string `json:"chunk_unit,omitempty"`
 }
 
+// maxSummarySentences caps the requested summary length
+const maxSummarySentences = 20
+
 // ArticleResponse represents the response for article extraction
 type ArticleResponse struct {
-	URL         string               `json:"url"`
-	Title       string               `json:"title"`
-	Content     string               `json:"content"`
-	Markdown    string               `json:"markdown,omitempty"`
-	Author      string               `json:"author,omitempty"`
-	Excerpt     string               `json:"excerpt,omitempty"`
-	Length      int                  `json:"length"`
-	PublishedAt string               `json:"published_at,omitempty"`
-	OpenGraph   *utils.OpenGraphData `json:"open_graph,omitempty"`
-	Success     bool                 `json:"success"`
-	Message     string               `json:"message,omitempty"`
+	URL                string               `json:"url"`
+	Title              string               `json:"title"`
+	Content            string               `json:"content"`
+	Markdown           string               `json:"markdown,omitempty"`
+	Author             string               `json:"author,omitempty"`
+	Authors            []utils.Author       `json:"authors,omitempty"`
+	Excerpt            string               `json:"excerpt,omitempty"`
+	Summary            []string             `json:"summary,omitempty"`
+	Length             int                  `json:"length"`
+	PublishedAt        string               `json:"published_at,omitempty"`
+	ModifiedAt         string               `json:"modified_at,omitempty"`
+	Dates              *utils.ArticleDates  `json:"dates,omitempty"`
+	Language           string               `json:"language,omitempty"`
+	LanguageConfidence float64              `json:"language_confidence,omitempty"`
+	Dir                string               `json:"dir,omitempty"`
+	Stats              *utils.ArticleStats  `json:"stats,omitempty"`
+	TOC                []utils.TOCEntry     `json:"toc,omitempty"`
+	Images             []utils.ArticleImage `json:"images,omitempty"`
+	Embeds             []utils.Embed        `json:"embeds,omitempty"`
+	Links              []utils.ArticleLink  `json:"links,omitempty"`
+	Footnotes          []utils.Footnote     `json:"footnotes,omitempty"`
+	Keywords           []utils.Keyword      `json:"keywords,omitempty"`
+	Chunks             []utils.Chunk        `json:"chunks,omitempty"`
+	Encoding           string               `json:"encoding,omitempty"`
+	OpenGraph          *utils.OpenGraphData `json:"open_graph,omitempty"`
+	Success            bool                 `json:"success"`
+	Message            string               `json:"message,omitempty"`
+}
+
+// newArticleResponse builds a successful response from a cleaned article, without markdown
+func newArticleResponse(cleanedArticle utils.CleanedArticle) ArticleResponse {
+	return ArticleResponse{
+		URL:                cleanedArticle.URL,
+		Title:              cleanedArticle.Title,
+		Content:            cleanedArticle.Content,
+		Author:             cleanedArticle.Author,
+		Authors:            cleanedArticle.Authors,
+		Excerpt:            cleanedArticle.Excerpt,
+		Summary:            cleanedArticle.Summary,
+		Length:             cleanedArticle.Length,
+		PublishedAt:        cleanedArticle.PublishedAt,
+		ModifiedAt:         cleanedArticle.ModifiedAt,
+		Dates:              cleanedArticle.Dates,
+		Language:           cleanedArticle.Language,
+		LanguageConfidence: cleanedArticle.LanguageConfidence,
+		Dir:                cleanedArticle.Dir,
+		Stats:              cleanedArticle.Stats,
+		TOC:                cleanedArticle.TOC,
+		Images:             cleanedArticle.Images,
+		Embeds:             cleanedArticle.Embeds,
+		Links:              cleanedArticle.Links,
+		Footnotes:          cleanedArticle.Footnotes,
+		Keywords:           cleanedArticle.Keywords,
+		Chunks:             cleanedArticle.Chunks,
+		Encoding:           cleanedArticle.Encoding,
+		OpenGraph:          cleanedArticle.OpenGraph,
+		Success:            true,
+	}
+}
+
+// splitQueryList splits a comma-separated query parameter into its trimmed, non-empty values
+func splitQueryList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// newMarkdownOptions applies the requested markdown settings to the defaults
+func newMarkdownOptions(flavor, headingStyle, bulletMarker, linkStyle string, dropImages bool, maxHeadingLevel, wrapWidth int) (utils.MarkdownOptions, error) {
+	options := utils.DefaultMarkdownOptions()
+	if flavor != "" {
+		options.Flavor = flavor
+	}
+	if headingStyle != "" {
+		options.HeadingStyle = headingStyle
+	}
+	if bulletMarker != "" {
+		options.BulletListMarker = bulletMarker
+	}
+	if linkStyle != "" {
+		options.LinkStyle = linkStyle
+	}
+	options.DropImages = dropImages
+	options.MaxHeadingLevel = maxHeadingLevel
+	options.WrapWidth = wrapWidth
+	return options, options.Validate()
+}
+
+// newChunkOptions applies the requested chunking settings to the defaults
+func newChunkOptions(size int, overlap *int, unit string) (utils.ChunkOptions, error) {
+	options := utils.DefaultChunkOptions()
+	switch unit {
+	case "":
+	case utils.ChunkUnitCharacters, utils.ChunkUnitTokens:
+		options.Unit = unit
+	default:
+		return options, fmt.Errorf("unknown chunk unit %q", unit)
+	}
+	if size > 0 {
+		options.TargetSize = size
+	}
+	if overlap != nil {
+		options.Overlap = *overlap
+	}
+	if options.Overlap < 0 || options.Overlap >= options.TargetSize {
+		return options, fmt.Errorf("chunk overlap must be between 0 and the chunk size")
+	}
+	return options, nil
 }
 
 // ExtractArticleHandler handles article extraction requests
@@ -44,8 +192,61 @@ func (s *Server) ExtractArticleHandler(c *gin.Context) {
 
 	s.logger.Infow("Processing article extraction request", "url", req.URL, "include_markdown", req.IncludeMarkdown)
 
+	options := utils.DefaultArticleOptions()
+	if len(req.ImageFormats) > 0 {
+		options.ImageFormats = req.ImageFormats
+	}
+	options.TrackingParams = append(options.TrackingParams, req.TrackingParams...)
+	options.RewriteLinks = req.RewriteLinks
+	options.IncludeTOC = req.IncludeTOC
+	if req.WordsPerMinute > 0 {
+		options.WordsPerMinute = req.WordsPerMinute
+	}
+	if req.SummarySentences > 0 {
+		options.SummarySentences = min(req.SummarySentences, maxSummarySentences)
+	}
+	if req.ExcerptMode != "" {
+		options.ExcerptMode = req.ExcerptMode
+	}
+	if req.ExcerptLength > 0 {
+		options.ExcerptLength = req.ExcerptLength
+	}
+	options.KeywordCount = req.KeywordCount
+	options.KeywordLanguage = req.KeywordLanguage
+	options.KeywordStopwords = req.KeywordStopwords
+	markdownOptions, err := newMarkdownOptions(req.MarkdownFlavor, req.HeadingStyle, req.BulletMarker, req.LinkStyle, req.DropImages, req.MaxHeadingLevel, req.WrapWidth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ArticleResponse{
+			URL:     req.URL,
+			Success: false,
+			Message: "Invalid markdown options: " + err.Error(),
+		})
+		return
+	}
+	options.Markdown = markdownOptions
+	if err := options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, ArticleResponse{
+			URL:     req.URL,
+			Success: false,
+			Message: "Invalid options: " + err.Error(),
+		})
+		return
+	}
+	if req.Chunks {
+		chunkOptions, err := newChunkOptions(req.ChunkSize, req.ChunkOverlap, req.ChunkUnit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ArticleResponse{
+				URL:     req.URL,
+				Success: false,
+				Message: "Invalid chunk options: " + err.Error(),
+			})
+			return
+		}
+		options.Chunking = &chunkOptions
+	}
+
 	// Extract article content using the enhanced cleaner
-	cleanedArticle := utils.GetCleanedArticle(req.URL)
+	cleanedArticle := utils.GetCleanedArticleWithOptions(req.URL, options)
 
 	if cleanedArticle.Title == "" && cleanedArticle.Content == "" {
 		s.logger.Warnw("Failed to extract article content", "url", req.URL)
@@ -57,17 +258,7 @@ func (s *Server) ExtractArticleHandler(c *gin.Context) {
 		return
 	}
 
-	response := ArticleResponse{
-		URL:         cleanedArticle.URL,
-		Title:       cleanedArticle.Title,
-		Content:     cleanedArticle.Content,
-		Author:      cleanedArticle.Author,
-		Excerpt:     cleanedArticle.Excerpt,
-		Length:      cleanedArticle.Length,
-		PublishedAt: cleanedArticle.PublishedAt,
-		OpenGraph:   cleanedArticle.OpenGraph,
-		Success:     true,
-	}
+	response := newArticleResponse(cleanedArticle)
 
 	// Include markdown if requested
 	if req.IncludeMarkdown {
@@ -102,8 +293,70 @@ func (s *Server) ExtractArticleSimpleHandler(c *gin.Context) {
 
 	s.logger.Infow("Processing simple article extraction", "url", url, "include_markdown", includeMarkdown)
 
+	options := utils.DefaultArticleOptions()
+	if formats := splitQueryList(c.Query("image_formats")); len(formats) > 0 {
+		options.ImageFormats = formats
+	}
+	options.TrackingParams = append(options.TrackingParams, splitQueryList(c.Query("tracking_params"))...)
+	options.RewriteLinks = c.Query("rewrite_links") == "true"
+	options.IncludeTOC = c.Query("toc") == "true"
+	if wpm, err := strconv.Atoi(c.Query("wpm")); err == nil && wpm > 0 {
+		options.WordsPerMinute = wpm
+	}
+	if sentences, err := strconv.Atoi(c.Query("summary_sentences")); err == nil && sentences > 0 {
+		options.SummarySentences = min(sentences, maxSummarySentences)
+	}
+	if mode := c.Query("excerpt_mode"); mode != "" {
+		options.ExcerptMode = mode
+	}
+	if length, err := strconv.Atoi(c.Query("excerpt_length")); err == nil && length > 0 {
+		options.ExcerptLength = length
+	}
+	if count, err := strconv.Atoi(c.Query("keyword_count")); err == nil {
+		options.KeywordCount = count
+	}
+	options.KeywordLanguage = c.Query("keyword_language")
+	options.KeywordStopwords = splitQueryList(c.Query("keyword_stopwords"))
+	maxHeadingLevel, _ := strconv.Atoi(c.Query("max_heading_level"))
+	wrapWidth, _ := strconv.Atoi(c.Query("wrap_width"))
+	markdownOptions, err := newMarkdownOptions(c.Query("markdown_flavor"), c.Query("heading_style"), c.Query("bullet_marker"), c.Query("link_style"), c.Query("drop_images") == "true", maxHeadingLevel, wrapWidth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ArticleResponse{
+			URL:     url,
+			Success: false,
+			Message: "Invalid markdown options: " + err.Error(),
+		})
+		return
+	}
+	options.Markdown = markdownOptions
+	if err := options.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, ArticleResponse{
+			URL:     url,
+			Success: false,
+			Message: "Invalid options: " + err.Error(),
+		})
+		return
+	}
+	if c.Query("chunks") == "true" {
+		size, _ := strconv.Atoi(c.Query("chunk_size"))
+		var overlap *int
+		if value, err := strconv.Atoi(c.Query("chunk_overlap")); err == nil {
+			overlap = &value
+		}
+		chunkOptions, err := newChunkOptions(size, overlap, c.Query("chunk_unit"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ArticleResponse{
+				URL:     url,
+				Success: false,
+				Message: "Invalid chunk options: " + err.Error(),
+			})
+			return
+		}
+		options.Chunking = &chunkOptions
+	}
+
 	// Extract article content using the enhanced cleaner
-	cleanedArticle := utils.GetCleanedArticle(url)
+	cleanedArticle := utils.GetCleanedArticleWithOptions(url, options)
 
 	if cleanedArticle.Title == "" && cleanedArticle.Content == "" {
 		s.logger.Warnw("Failed to extract article content", "url", url)
@@ -115,17 +368,7 @@ func (s *Server) ExtractArticleSimpleHandler(c *gin.Context) {
 		return
 	}
 
-	response := ArticleResponse{
-		URL:         cleanedArticle.URL,
-		Title:       cleanedArticle.Title,
-		Content:     cleanedArticle.Content,
-		Author:      cleanedArticle.Author,
-		Excerpt:     cleanedArticle.Excerpt,
-		Length:      cleanedArticle.Length,
-		PublishedAt: cleanedArticle.PublishedAt,
-		OpenGraph:   cleanedArticle.OpenGraph,
-		Success:     true,
-	}
+	response := newArticleResponse(cleanedArticle)
 
 	// Include markdown if requested
 	if includeMarkdown {
@@ -242,3 +485,205 @@ func (s *Server) ExtractOpenGraphSimpleHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ArchiveRequest represents the request body for article archiving
+type ArchiveRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Format is "html" for a self-contained HTML file or "zip" for a bundle with an assets folder
+	Format        string   `json:"format,omitempty"`
+	ImageFormats  []string `json:"image_formats,omitempty"`
+	MaxAssets     int      `json:"max_assets,omitempty"`
+	MaxAssetBytes int64    `json:"max_asset_bytes,omitempty"`
+}
+
+// ArchiveArticleHandler handles article archiving requests and responds with the archive file
+func (s *Server) ArchiveArticleHandler(c *gin.Context) {
+	s.logger.Info("ArchiveArticleHandler called")
+
+	var req ArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.logger.Errorw("Invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, ArticleResponse{
+			Success: false,
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	s.archiveArticle(c, req)
+}
+
+// ArchiveArticleSimpleHandler handles simple GET requests for article archiving
+func (s *Server) ArchiveArticleSimpleHandler(c *gin.Context) {
+	s.logger.Info("ArchiveArticleSimpleHandler called")
+
+	req := ArchiveRequest{
+		URL:          c.Query("url"),
+		Format:       c.Query("format"),
+		ImageFormats: splitQueryList(c.Query("image_formats")),
+	}
+	if req.URL == "" {
+		s.logger.Warn("URL parameter missing")
+		c.JSON(http.StatusBadRequest, ArticleResponse{
+			Success: false,
+			Message: "URL parameter is required",
+		})
+		return
+	}
+	req.MaxAssets, _ = strconv.Atoi(c.Query("max_assets"))
+	req.MaxAssetBytes, _ = strconv.ParseInt(c.Query("max_asset_bytes"), 10, 64)
+
+	s.archiveArticle(c, req)
+}
+
+// archiveArticle builds the requested archive and writes it as an attachment
+func (s *Server) archiveArticle(c *gin.Context, req ArchiveRequest) {
+	options := utils.DefaultArticleOptions()
+	if len(req.ImageFormats) > 0 {
+		options.ImageFormats = req.ImageFormats
+	}
+
+	archiveOptions := utils.DefaultArchiveOptions()
+	if req.Format != "" {
+		archiveOptions.Format = req.Format
+	}
+	if archiveOptions.Format != utils.ArchiveFormatHTML && archiveOptions.Format != utils.ArchiveFormatZip {
+		c.JSON(http.StatusBadRequest, ArticleResponse{
+			URL:     req.URL,
+			Success: false,
+			Message: "Unsupported archive format: " + archiveOptions.Format,
+		})
+		return
+	}
+	// Requests may tighten the limits but not raise them
+	if req.MaxAssets > 0 && req.MaxAssets < archiveOptions.MaxAssets {
+		archiveOptions.MaxAssets = req.MaxAssets
+	}
+	if req.MaxAssetBytes > 0 && req.MaxAssetBytes < archiveOptions.MaxAssetBytes {
+		archiveOptions.MaxAssetBytes = req.MaxAssetBytes
+	}
+
+	s.logger.Infow("Processing article archive request", "url", req.URL, "format", archiveOptions.Format)
+
+	archive, err := utils.GetArticleArchive(c.Request.Context(), req.URL, options, archiveOptions)
+	if err != nil {
+		s.logger.Warnw("Failed to archive article", "url", req.URL, "error", err)
+		c.JSON(http.StatusInternalServerError, ArticleResponse{
+			URL:     req.URL,
+			Success: false,
+			Message: "Failed to archive article",
+		})
+		return
+	}
+
+	s.logger.Infow("Successfully archived article",
+		"url", req.URL,
+		"format", archiveOptions.Format,
+		"assets", len(archive.Assets),
+		"skipped", len(archive.Skipped),
+	)
+
+	c.Header("Content-Disposition", `attachment; filename="`+archive.Filename+`"`)
+	c.Header("X-Archive-Assets", strconv.Itoa(len(archive.Assets)))
+	c.Header("X-Archive-Skipped", strconv.Itoa(len(archive.Skipped)))
+	c.Data(http.StatusOK, archive.ContentType, archive.Data)
+}
+
+// CleanURLRequest represents the request body for URL cleaning
+type CleanURLRequest struct {
+	URL string `json:"url" binding:"required"`
+	// TrackingParams adds query parameter rules to the built-in list, e.g. ["ref", "src_*"]
+	TrackingParams []string `json:"tracking_params,omitempty"`
+	// ExpandShortLinks follows known URL shorteners such as t.co and bit.ly
+	ExpandShortLinks bool `json:"expand_short_links,omitempty"`
+}
+
+// CleanURLResponse represents the response for URL cleaning
+type CleanURLResponse struct {
+	URL      string `json:"url"`
+	CleanURL string `json:"clean_url,omitempty"`
+	Success  bool   `json:"success"`
+	Message  string `json:"message,omitempty"`
+}
+
+// CleanURLHandler removes redirect wrappers and tracking parameters from a URL
+func (s *Server) CleanURLHandler(c *gin.Context) {
+	s.logger.Info("CleanURLHandler called")
+
+	var req CleanURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.logger.Errorw("Invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, CleanURLResponse{
+			Success: false,
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	trackingParams := append(utils.DefaultArticleOptions().TrackingParams, req.TrackingParams...)
+	cleanURL, err := utils.GetCleanURL(req.URL, trackingParams, req.ExpandShortLinks)
+	if err != nil {
+		s.logger.Warnw("Failed to clean URL", "url", req.URL, "error", err)
+		c.JSON(http.StatusBadRequest, CleanURLResponse{
+			URL:     req.URL,
+			Success: false,
+			Message: "Invalid URL: " + err.Error(),
+		})
+		return
+	}
+
+	s.logger.Infow("Cleaned URL", "url", req.URL, "clean_url", cleanURL)
+
+	c.JSON(http.StatusOK, CleanURLResponse{
+		URL:      req.URL,
+		CleanURL: cleanURL,
+		Success:  true,
+	})
+}
+
+// ChunkRequest represents the request body for markdown chunking
+type ChunkRequest struct {
+	Markdown     string `json:"markdown" binding:"required"`
+	ChunkSize    int    `json:"chunk_size,omitempty"`
+	ChunkOverlap *int   `json:"chunk_overlap,omitempty"`
+	ChunkUnit    string `json:"chunk_unit,omitempty"`
+}
+
+// ChunkResponse represents the response for markdown chunking
+type ChunkResponse struct {
+	Chunks  []utils.Chunk `json:"chunks"`
+	Success bool          `json:"success"`
+	Message string        `json:"message,omitempty"`
+}
+
+// ChunkMarkdownHandler splits markdown into chunks for retrieval
+func (s *Server) ChunkMarkdownHandler(c *gin.Context) {
+	s.logger.Info("ChunkMarkdownHandler called")
+
+	var req ChunkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.logger.Errorw("Invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, ChunkResponse{
+			Success: false,
+			Message: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	options, err := newChunkOptions(req.ChunkSize, req.ChunkOverlap, req.ChunkUnit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ChunkResponse{
+			Success: false,
+			Message: "Invalid chunk options: " + err.Error(),
+		})
+		return
+	}
+
+	chunks := utils.ChunkMarkdown(req.Markdown, options)
+	s.logger.Infow("Chunked markdown", "length", len(req.Markdown), "chunks", len(chunks))
+
+	c.JSON(http.StatusOK, ChunkResponse{
+		Chunks:  chunks,
+		Success: true,
+	})
+}
@@ -27,6 +27,10 @@ func (s *Server) RegisterRoutes() http.Handler {
 	r.GET("/extract", s.ExtractArticleSimpleHandler)
 	r.POST("/opengraph", s.ExtractOpenGraphHandler)
 	r.GET("/opengraph", s.ExtractOpenGraphSimpleHandler)
+	r.POST("/archive", s.ArchiveArticleHandler)
+	r.GET("/archive", s.ArchiveArticleSimpleHandler)
+	r.POST("/url/clean", s.CleanURLHandler)
+	r.POST("/chunk", s.ChunkMarkdownHandler)
 
 	return r
 }
@@ -0,0 +1,412 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Archive output formats
+const (
+	ArchiveFormatHTML = "html"
+	ArchiveFormatZip  = "zip"
+)
+
+// Default limits applied to archived assets
+const (
+	defaultArchiveMaxAssets     = 50
+	defaultArchiveMaxAssetBytes = 10 << 20
+	defaultArchiveMaxTotalBytes = 50 << 20
+)
+
+// assetFetchTimeout bounds how long a single asset download may take
+const assetFetchTimeout = 10 * time.Second
+
+// archiveAssetsTimeout bounds how long downloading all the assets of an archive may take; assets
+// left when it passes keep their original URL
+const archiveAssetsTimeout = 60 * time.Second
+
+var (
+	// assetClient downloads archived assets
+	assetClient = &http.Client{Timeout: assetFetchTimeout}
+
+	// errAssetTooLarge is returned when an asset exceeds the per-asset size limit
+	errAssetTooLarge = errors.New("asset exceeds size limit")
+
+	// assetExtensions maps image content types to the file extension used in zip bundles
+	assetExtensions = map[string]string{
+		"image/jpeg":    ".jpg",
+		"image/png":     ".png",
+		"image/gif":     ".gif",
+		"image/webp":    ".webp",
+		"image/avif":    ".avif",
+		"image/svg+xml": ".svg",
+		"image/bmp":     ".bmp",
+		"image/x-icon":  ".ico",
+	}
+)
+
+// ArchiveOptions controls how an article is archived
+type ArchiveOptions struct {
+	// Format is ArchiveFormatHTML for a self-contained HTML file or ArchiveFormatZip for a bundle
+	Format        string
+	MaxAssets     int
+	MaxAssetBytes int64
+	MaxTotalBytes int64
+}
+
+// DefaultArchiveOptions returns a self-contained HTML archive with the default asset limits
+func DefaultArchiveOptions() ArchiveOptions {
+	return ArchiveOptions{
+		Format:        ArchiveFormatHTML,
+		MaxAssets:     defaultArchiveMaxAssets,
+		MaxAssetBytes: defaultArchiveMaxAssetBytes,
+		MaxTotalBytes: defaultArchiveMaxTotalBytes,
+	}
+}
+
+// ArchivedAsset describes an asset downloaded into an archive
+type ArchivedAsset struct {
+	URL         string `json:"url"`
+	Path        string `json:"path,omitempty"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// ArticleArchive is an offline copy of an article
+type ArticleArchive struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	Assets      []ArchivedAsset
+	// Skipped lists asset URLs left pointing at the origin because they failed or hit a limit
+	Skipped []string
+}
+
+// downloadedAsset is an asset fetched for an archive
+type downloadedAsset struct {
+	ArchivedAsset
+	data []byte
+}
+
+// ArchiveArticle cleans an article and packages it with its images for offline reading. Asset
+// downloads stop when ctx is done or archiveAssetsTimeout has passed.
+func (ac *ArticleCleaner) ArchiveArticle(ctx context.Context, pageURL string, options ArticleOptions, archiveOptions ArchiveOptions) (*ArticleArchive, error) {
+	if archiveOptions.Format != ArchiveFormatHTML && archiveOptions.Format != ArchiveFormatZip {
+		return nil, fmt.Errorf("unsupported archive format %q", archiveOptions.Format)
+	}
+
+	article, err := ac.CleanArticleWithOptions(pageURL, options)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(article.html))
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &ArticleArchive{}
+	ctx, cancel := context.WithTimeout(ctx, archiveAssetsTimeout)
+	defer cancel()
+	assets := ac.downloadArticleAssets(ctx, doc, archiveOptions, archive)
+
+	// Point images at the archived copies
+	doc.Find("img[src]").Each(func(i int, img *goquery.Selection) {
+		asset, ok := assets[img.AttrOr("src", "")]
+		if !ok {
+			return
+		}
+		if archiveOptions.Format == ArchiveFormatZip {
+			img.SetAttr("src", asset.Path)
+		} else {
+			img.SetAttr("src", "data:"+asset.ContentType+";base64,"+base64.StdEncoding.EncodeToString(asset.data))
+		}
+	})
+
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return nil, err
+	}
+	page := renderArchiveHTML(article, body)
+
+	name := archiveFilename(article.Title)
+	if archiveOptions.Format == ArchiveFormatHTML {
+		archive.Filename = name + ".html"
+		archive.ContentType = "text/html; charset=utf-8"
+		archive.Data = []byte(page)
+	} else {
+		// The same markdown /extract returns, with the images pointing at the bundled copies
+		markdown := ac.renderArticleMarkdown(body, article.headings, article.Footnotes, article.Dir, options)
+		data, err := buildArchiveZip(page, renderArchiveMarkdown(article, markdown), archive.Assets, assets)
+		if err != nil {
+			return nil, err
+		}
+		archive.Filename = name + ".zip"
+		archive.ContentType = "application/zip"
+		archive.Data = data
+	}
+
+	ac.logger.Infow("Archived article",
+		"url", pageURL,
+		"format", archiveOptions.Format,
+		"assets", len(archive.Assets),
+		"skipped", len(archive.Skipped),
+		"size", len(archive.Data),
+	)
+
+	return archive, nil
+}
+
+// downloadArticleAssets downloads the article images within the archive limits and until ctx is
+// done, keyed by their URL
+func (ac *ArticleCleaner) downloadArticleAssets(ctx context.Context, doc *goquery.Document, options ArchiveOptions, archive *ArticleArchive) map[string]*downloadedAsset {
+	assets := make(map[string]*downloadedAsset)
+	var totalBytes int64
+
+	doc.Find("img[src]").Each(func(i int, img *goquery.Selection) {
+		src := strings.TrimSpace(img.AttrOr("src", ""))
+		if src == "" || strings.HasPrefix(strings.ToLower(src), "data:") || assets[src] != nil || slices.Contains(archive.Skipped, src) {
+			return
+		}
+		if options.MaxAssets > 0 && len(archive.Assets) >= options.MaxAssets {
+			archive.Skipped = append(archive.Skipped, src)
+			return
+		}
+		if ctx.Err() != nil {
+			archive.Skipped = append(archive.Skipped, src)
+			return
+		}
+
+		data, contentType, err := ac.fetchAsset(ctx, src, options.MaxAssetBytes)
+		if err != nil {
+			ac.logger.Warnw("Failed to archive asset", "url", src, "error", err)
+			archive.Skipped = append(archive.Skipped, src)
+			return
+		}
+		if options.MaxTotalBytes > 0 && totalBytes+int64(len(data)) > options.MaxTotalBytes {
+			ac.logger.Warnw("Archive size limit reached", "url", src, "limit", options.MaxTotalBytes)
+			archive.Skipped = append(archive.Skipped, src)
+			return
+		}
+		totalBytes += int64(len(data))
+
+		asset := &downloadedAsset{
+			ArchivedAsset: ArchivedAsset{
+				URL:         src,
+				Path:        fmt.Sprintf("assets/%03d%s", len(archive.Assets)+1, assetExtension(src, contentType)),
+				ContentType: contentType,
+				Size:        len(data),
+			},
+			data: data,
+		}
+		if options.Format != ArchiveFormatZip {
+			asset.Path = ""
+		}
+		assets[src] = asset
+		archive.Assets = append(archive.Assets, asset.ArchivedAsset)
+	})
+
+	if ctx.Err() != nil {
+		ac.logger.Warnw("Stopped archiving assets", "error", ctx.Err(), "skipped", len(archive.Skipped))
+	}
+
+	return assets
+}
+
+// fetchAsset downloads an image, refusing non-image responses and bodies over maxBytes
+func (ac *ArticleCleaner) fetchAsset(ctx context.Context, assetURL string, maxBytes int64) ([]byte, string, error) {
+	parsed, err := url.Parse(assetURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, "", fmt.Errorf("unsupported asset scheme %q", parsed.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", assetURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := assetClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected asset status code %d", resp.StatusCode)
+	}
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return nil, "", errAssetTooLarge
+	}
+
+	reader := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, "", errAssetTooLarge
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("unexpected asset content type %q", contentType)
+	}
+
+	return data, contentType, nil
+}
+
+// assetExtension picks the file extension for an asset from its content type or URL
+func assetExtension(assetURL, contentType string) string {
+	if ext, ok := assetExtensions[contentType]; ok {
+		return ext
+	}
+	if parsed, err := url.Parse(assetURL); err == nil {
+		if ext := strings.ToLower(path.Ext(parsed.Path)); ext != "" && len(ext) <= 5 {
+			return ext
+		}
+	}
+	return ".img"
+}
+
+// renderArchiveHTML wraps the article body in a standalone HTML document
+func renderArchiveHTML(article CleanedArticle, body string) string {
+	lang := ""
+	if article.Language != "" {
+		lang = ` lang="` + html.EscapeString(article.Language) + `"`
+	}
+	dir := ""
+	if article.Dir != "" {
+		dir = ` dir="` + html.EscapeString(article.Dir) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n")
+	fmt.Fprintf(&b, "<html%s%s>\n<head>\n<meta charset=\"utf-8\">\n", lang, dir)
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(article.Title))
+	fmt.Fprintf(&b, "<link rel=\"canonical\" href=\"%s\">\n", html.EscapeString(article.URL))
+	b.WriteString("<style>body{max-width:42em;margin:2em auto;padding:0 1em;font-family:Georgia,serif;line-height:1.6}img{max-width:100%;height:auto}</style>\n")
+	b.WriteString("</head>\n<body>\n<article>\n")
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", html.EscapeString(article.Title))
+	if article.Author != "" {
+		fmt.Fprintf(&b, "<p class=\"byline\">%s</p>\n", html.EscapeString(article.Author))
+	}
+	b.WriteString(body)
+	fmt.Fprintf(&b, "\n<footer><p>Archived from <a href=\"%s\">%s</a></p></footer>\n", html.EscapeString(article.URL), html.EscapeString(article.URL))
+	b.WriteString("</article>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// renderArchiveMarkdown prefixes the article markdown with its title and source
+func renderArchiveMarkdown(article CleanedArticle, markdown string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", article.Title)
+	if article.Author != "" {
+		fmt.Fprintf(&b, "By %s\n\n", article.Author)
+	}
+	fmt.Fprintf(&b, "Source: <%s>\n\n", article.URL)
+	b.WriteString(markdown)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// buildArchiveZip writes index.html, index.md and the downloaded assets into a zip file
+func buildArchiveZip(page, markdown string, archived []ArchivedAsset, assets map[string]*downloadedAsset) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"index.html", []byte(page)},
+		{"index.md", []byte(markdown)},
+	}
+	for _, asset := range archived {
+		files = append(files, struct {
+			name string
+			data []byte
+		}{asset.Path, assets[asset.URL].data})
+	}
+
+	for _, file := range files {
+		w, err := writer.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// archiveFilename derives a file name from the article title
+func archiveFilename(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteRune('-')
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if name == "" {
+		return "article"
+	}
+	return name
+}
+
+// GetArticleArchive returns an offline archive of the article at url, downloading assets until ctx is done
+func GetArticleArchive(ctx context.Context, url string, options ArticleOptions, archiveOptions ArchiveOptions) (*ArticleArchive, error) {
+	cleaner, err := NewArticleCleaner()
+	if err != nil {
+		return nil, err
+	}
+	defer cleaner.Close()
+
+	archive, err := cleaner.ArchiveArticle(ctx, url, options, archiveOptions)
+	if err != nil {
+		cleaner.logger.Errorw("Failed to archive article", "url", url, "error", err)
+		return nil, err
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestArchiveArticle(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+
+	articleHTML := `<html>
+<head><title>Archive Me</title></head>
+<body>
+	<article>
+		<h1>Archive Me</h1>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<figure><img src="/img/photo.png" alt="Photo"><figcaption>A photo</figcaption></figure>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+		<img src="/img/huge.png" alt="Huge">
+		<img src="/img/missing.png" alt="Missing">
+		<img src="/img/page.png" alt="Not an image">
+		<h2>Design Decisions</h2>
+		<p>And a third paragraph discussing the architecture decisions that were made during
+		the design phase of this particular system component.</p>
+	</article>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/img/photo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(pngData.Bytes())
+		case "/img/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(bytes.Repeat([]byte{0}, 4096))
+		case "/img/page.png":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Login required</body></html>"))
+		case "/img/missing.png":
+			http.NotFound(w, r)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(articleHTML))
+		}
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	archiveOptions := DefaultArchiveOptions()
+	archiveOptions.MaxAssetBytes = 1024
+
+	t.Run("self-contained html", func(t *testing.T) {
+		archive, err := ac.ArchiveArticle(context.Background(), ts.URL, DefaultArticleOptions(), archiveOptions)
+		if err != nil {
+			t.Fatalf("ArchiveArticle failed: %v", err)
+		}
+
+		if archive.Filename != "archive-me.html" || !strings.HasPrefix(archive.ContentType, "text/html") {
+			t.Errorf("Unexpected filename %q or content type %q", archive.Filename, archive.ContentType)
+		}
+		page := string(archive.Data)
+		if !strings.Contains(page, `src="data:image/png;base64,`) {
+			t.Error("Expected the photo to be inlined as a data URI")
+		}
+		if strings.Contains(page, ts.URL+"/img/photo.png") {
+			t.Error("Expected the photo URL to be replaced")
+		}
+		if !strings.Contains(page, ts.URL+"/img/huge.png") {
+			t.Error("Expected the oversized image to keep its original URL")
+		}
+		if len(archive.Assets) != 1 || len(archive.Skipped) != 3 {
+			t.Errorf("Expected 1 asset and 3 skipped, got %+v and %v", archive.Assets, archive.Skipped)
+		}
+	})
+
+	t.Run("zip bundle", func(t *testing.T) {
+		zipOptions := archiveOptions
+		zipOptions.Format = ArchiveFormatZip
+		options := DefaultArticleOptions()
+		options.IncludeTOC = true
+		archive, err := ac.ArchiveArticle(context.Background(), ts.URL, options, zipOptions)
+		if err != nil {
+			t.Fatalf("ArchiveArticle failed: %v", err)
+		}
+		if archive.Filename != "archive-me.zip" || archive.ContentType != "application/zip" {
+			t.Errorf("Unexpected filename %q or content type %q", archive.Filename, archive.ContentType)
+		}
+
+		reader, err := zip.NewReader(bytes.NewReader(archive.Data), int64(len(archive.Data)))
+		if err != nil {
+			t.Fatalf("Failed to open zip: %v", err)
+		}
+		files := make(map[string]string)
+		for _, file := range reader.File {
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatalf("Failed to open %s: %v", file.Name, err)
+			}
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			files[file.Name] = string(data)
+		}
+
+		if files["assets/001.png"] != pngData.String() {
+			t.Error("Expected assets/001.png to contain the downloaded photo")
+		}
+		if !strings.Contains(files["index.html"], `src="assets/001.png"`) {
+			t.Error("Expected index.html to reference the local asset")
+		}
+		if !strings.Contains(files["index.md"], "](assets/001.png)") || !strings.HasPrefix(files["index.md"], "# Archive Me") {
+			t.Errorf("Unexpected index.md:\n%s", files["index.md"])
+		}
+		if article, err := ac.CleanArticleWithOptions(ts.URL, options); err != nil || !strings.Contains(files["index.md"], strings.ReplaceAll(article.Markdown, ts.URL+"/img/photo.png", "assets/001.png")) {
+			t.Errorf("Expected index.md to contain the /extract markdown with its anchors and outline, got:\n%s", files["index.md"])
+		}
+		if !strings.Contains(files["index.md"], `<a id="design-decisions"></a>`) || !strings.Contains(files["index.md"], "- [Design Decisions](#design-decisions)") {
+			t.Errorf("Expected heading anchors and a table of contents in index.md, got:\n%s", files["index.md"])
+		}
+	})
+
+	t.Run("total size limit", func(t *testing.T) {
+		limited := archiveOptions
+		limited.MaxTotalBytes = 10
+		archive, err := ac.ArchiveArticle(context.Background(), ts.URL, DefaultArticleOptions(), limited)
+		if err != nil {
+			t.Fatalf("ArchiveArticle failed: %v", err)
+		}
+		if len(archive.Assets) != 0 {
+			t.Errorf("Expected the total size limit to skip every asset, got %+v", archive.Assets)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		archive, err := ac.ArchiveArticle(ctx, ts.URL, DefaultArticleOptions(), archiveOptions)
+		if err != nil {
+			t.Fatalf("ArchiveArticle failed: %v", err)
+		}
+		if len(archive.Assets) != 0 || len(archive.Skipped) != 4 {
+			t.Errorf("Expected every asset to be skipped once the context is done, got %+v and %v", archive.Assets, archive.Skipped)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		if _, err := ac.ArchiveArticle(context.Background(), ts.URL, DefaultArticleOptions(), ArchiveOptions{Format: "pdf"}); err == nil {
+			t.Error("Expected an error for an unsupported format")
+		}
+	})
+}
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Author represents a single article author
+type Author struct {
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+	Role   string `json:"role,omitempty"`
+}
+
+var (
+	// bylinePrefixPattern matches leading phrases like "By", "Written by" or "Posted by:"
+	bylinePrefixPattern = regexp.MustCompile(`(?i)^\s*(?:(?:written|posted|reported|story|words|text)\s+)?by\s*:?\s+`)
+	// bylineSplitPattern matches separators between multiple author names, other than plain commas
+	bylineSplitPattern = regexp.MustCompile(`(?i)\s*(?:,\s*and\s+|\s+and\s+|\s*&\s*|\s*;\s*)`)
+	// bylineCommaPattern matches commas, which separate authors in lists but also "Last, First" names
+	bylineCommaPattern = regexp.MustCompile(`\s*,\s*`)
+	// bylineRolePattern matches role or title segments such as "Staff Writer" or "Senior Editor"
+	bylineRolePattern = regexp.MustCompile(`(?i)^(?:(?:senior|staff|special|contributing|guest|chief|managing|associate|deputy|executive|foreign|national|political|science|technology|tech|health|business|sports|news)\s+)*(?:writer|editor|reporter|correspondent|contributor|columnist|journalist|staff|photographer|critic|producer|analyst|intern)s?$`)
+	// bylineTrailerPattern matches separators after which a byline carries dates or sections
+	bylineTrailerPattern = regexp.MustCompile(`\s*[|•·—]\s*`)
+)
+
+// jsonLDAuthorRoles maps JSON-LD properties to the author role they describe
+var jsonLDAuthorRoles = []struct {
+	property string
+	role     string
+}{
+	{"author", "author"},
+	{"creator", "author"},
+	{"contributor", "contributor"},
+	{"editor", "editor"},
+}
+
+// extractAuthors collects authors from JSON-LD, article:author, meta author and rel="author" links
+func (ac *ArticleCleaner) extractAuthors(doc *goquery.Document, jsonLD []map[string]interface{}, baseURL *url.URL) []Author {
+	var authors []Author
+
+	// JSON-LD author objects
+	for _, obj := range jsonLD {
+		for _, prop := range jsonLDAuthorRoles {
+			authors = append(authors, ac.jsonLDAuthors(obj[prop.property], prop.role, baseURL)...)
+		}
+	}
+
+	// article:author may hold either a name or a profile URL
+	doc.Find("meta[property='article:author']").Each(func(i int, s *goquery.Selection) {
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		if content == "" {
+			return
+		}
+		if isAbsoluteHTTPURL(content) {
+			authors = append(authors, Author{URL: content, Role: "author"})
+			return
+		}
+		for _, name := range splitByline(content) {
+			authors = append(authors, Author{Name: name, Role: "author"})
+		}
+	})
+
+	// Standard meta author
+	if content, exists := doc.Find("meta[name='author']").Attr("content"); exists {
+		for _, name := range splitByline(content) {
+			authors = append(authors, Author{Name: name, Role: "author"})
+		}
+	}
+
+	// rel="author" links
+	doc.Find("a[rel~='author'], link[rel~='author']").Each(func(i int, s *goquery.Selection) {
+		author := Author{
+			Name: cleanAuthorName(s.Text()),
+			URL:  ac.resolveURL(strings.TrimSpace(s.AttrOr("href", "")), baseURL),
+			Role: "author",
+		}
+		if src, exists := s.Find("img").Attr("src"); exists {
+			author.Avatar = ac.resolveURL(strings.TrimSpace(src), baseURL)
+		}
+		if author.Name == "" {
+			author.Name = cleanAuthorName(s.Find("img").AttrOr("alt", ""))
+		}
+		if author.Name != "" || author.URL != "" {
+			authors = append(authors, author)
+		}
+	})
+
+	return mergeAuthors(authors)
+}
+
+// jsonLDAuthors converts a JSON-LD author value (string, object or array) into authors
+func (ac *ArticleCleaner) jsonLDAuthors(value interface{}, role string, baseURL *url.URL) []Author {
+	var authors []Author
+
+	switch v := value.(type) {
+	case string:
+		for _, name := range splitByline(v) {
+			authors = append(authors, Author{Name: name, Role: role})
+		}
+	case []interface{}:
+		for _, item := range v {
+			authors = append(authors, ac.jsonLDAuthors(item, role, baseURL)...)
+		}
+	case map[string]interface{}:
+		author := Author{
+			Name: cleanAuthorName(jsonLDString(v["name"])),
+			URL:  ac.resolveURL(jsonLDURL(v["url"]), baseURL),
+			Role: role,
+		}
+		if author.URL == "" {
+			author.URL = ac.resolveURL(jsonLDURL(v["sameAs"]), baseURL)
+		}
+		author.Avatar = ac.resolveURL(jsonLDURL(v["image"]), baseURL)
+		if author.Name != "" || author.URL != "" {
+			authors = append(authors, author)
+		}
+	}
+
+	return authors
+}
+
+// jsonLDURL returns a URL from a JSON-LD value that may be a string, an ImageObject or an array
+func jsonLDURL(value interface{}) string {
+	if obj, ok := value.(map[string]interface{}); ok {
+		return jsonLDString(obj["url"])
+	}
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if u := jsonLDURL(item); u != "" {
+				return u
+			}
+		}
+		return ""
+	}
+	return jsonLDString(value)
+}
+
+// parseByline splits a readability byline like "By Jane Doe and John Smith" into authors
+func parseByline(byline string) []Author {
+	var authors []Author
+	for _, name := range splitByline(byline) {
+		authors = append(authors, Author{Name: name, Role: "author"})
+	}
+	return authors
+}
+
+// splitByline splits a byline into individual, cleaned author names
+func splitByline(byline string) []string {
+	byline = strings.TrimSpace(byline)
+	if byline == "" || isAbsoluteHTTPURL(byline) {
+		return nil
+	}
+
+	// Drop trailing dates, sections or publication names
+	if loc := bylineTrailerPattern.FindStringIndex(byline); loc != nil && loc[0] > 0 {
+		byline = byline[:loc[0]]
+	}
+	byline = bylinePrefixPattern.ReplaceAllString(byline, "")
+
+	var names []string
+	for _, segment := range bylineSplitPattern.Split(byline, -1) {
+		var parts []string
+		for _, part := range bylineCommaPattern.Split(segment, -1) {
+			if part = strings.TrimSpace(part); part != "" && !bylineRolePattern.MatchString(part) {
+				parts = append(parts, part)
+			}
+		}
+		// Commas separate full names or a longer list; two parts otherwise are "Last, First"
+		if len(parts) == 2 && !allMultiWord(parts) {
+			parts = []string{strings.Join(parts, ", ")}
+		}
+		for _, part := range parts {
+			if name := cleanAuthorName(part); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// allMultiWord reports whether every part has more than one word
+func allMultiWord(parts []string) bool {
+	for _, part := range parts {
+		if len(strings.Fields(part)) < 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanAuthorName trims prefixes, whitespace and stray punctuation from an author name
+func cleanAuthorName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	name = bylinePrefixPattern.ReplaceAllString(name, "")
+	name = strings.Trim(name, " ,;:-|.")
+	if len([]rune(name)) > 100 {
+		return ""
+	}
+	return name
+}
+
+// mergeAuthors deduplicates authors by name, filling in URL, avatar and role from repeated entries.
+// Nameless profile URLs are attached to the sole named author, or dropped when ambiguous.
+func mergeAuthors(candidates []Author) []Author {
+	var merged []Author
+	index := make(map[string]int)
+	var profileURLs []string
+
+	for _, candidate := range candidates {
+		if candidate.Name == "" {
+			if candidate.URL != "" {
+				profileURLs = append(profileURLs, candidate.URL)
+			}
+			continue
+		}
+
+		key := strings.ToLower(candidate.Name)
+		if i, ok := index[key]; ok {
+			if merged[i].URL == "" {
+				merged[i].URL = candidate.URL
+			}
+			if merged[i].Avatar == "" {
+				merged[i].Avatar = candidate.Avatar
+			}
+			if merged[i].Role == "" {
+				merged[i].Role = candidate.Role
+			}
+			continue
+		}
+
+		index[key] = len(merged)
+		merged = append(merged, candidate)
+	}
+
+	if len(merged) == 1 && merged[0].URL == "" && len(profileURLs) > 0 {
+		merged[0].URL = profileURLs[0]
+	}
+
+	return merged
+}
+
+// authorNames joins the names of credited authors (not editors or contributors) for the
+// single-string author fields
+func authorNames(authors []Author) string {
+	var names []string
+	for _, author := range authors {
+		if author.Name != "" && (author.Role == "" || author.Role == "author") {
+			names = append(names, author.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// isAbsoluteHTTPURL reports whether s is an absolute http(s) URL
+func isAbsoluteHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSplitByline(t *testing.T) {
+	tests := []struct {
+		byline string
+		want   []string
+	}{
+		{"By Jane Doe and John Smith", []string{"Jane Doe", "John Smith"}},
+		{"Written by Jane Doe, John Smith, and Ana Lima", []string{"Jane Doe", "John Smith", "Ana Lima"}},
+		{"Jane Doe & John Smith | January 5, 2024", []string{"Jane Doe", "John Smith"}},
+		{"by: Jane Doe", []string{"Jane Doe"}},
+		{"By Smith, John", []string{"Smith, John"}},
+		{"Doe, Jane; Smith, John", []string{"Doe, Jane", "Smith, John"}},
+		{"Jane Doe, John Smith", []string{"Jane Doe", "John Smith"}},
+		{"Jane Doe, Staff Writer", []string{"Jane Doe"}},
+		{"By John Smith, Senior Correspondent, and Jane Doe", []string{"John Smith", "Jane Doe"}},
+		{"Smith, John and Doe, Jane", []string{"Smith, John", "Doe, Jane"}},
+		{"Ana, Bo, Cy and Di", []string{"Ana", "Bo", "Cy", "Di"}},
+		{"https://www.facebook.com/janedoe", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitByline(tt.byline); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.byline, got, tt.want)
+		}
+	}
+}
+
+func TestExtractOpenGraphDataAuthors(t *testing.T) {
+	html := `<html>
+<head>
+	<title>Authors</title>
+	<meta property="article:author" content="https://www.facebook.com/janedoe" />
+	<meta name="author" content="Jane Doe and John Smith" />
+	<script type="application/ld+json">
+	{"@type": "Article", "author": [
+		{"@type": "Person", "name": "Jane Doe", "url": "/authors/jane", "image": {"@type": "ImageObject", "url": "/img/jane.jpg"}},
+		{"@type": "Person", "name": "John Smith"}
+	], "editor": "Ana Lima"}
+	</script>
+</head>
+<body>
+	<a rel="author" href="/authors/john">John Smith</a>
+	<p>Content</p>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(html))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	og, err := ac.ExtractOpenGraphData(ts.URL)
+	if err != nil {
+		t.Fatalf("ExtractOpenGraphData failed: %v", err)
+	}
+
+	want := []Author{
+		{Name: "Jane Doe", URL: ts.URL + "/authors/jane", Avatar: ts.URL + "/img/jane.jpg", Role: "author"},
+		{Name: "John Smith", URL: ts.URL + "/authors/john", Role: "author"},
+		{Name: "Ana Lima", Role: "editor"},
+	}
+	if !reflect.DeepEqual(og.Authors, want) {
+		t.Errorf("Authors:\n got  %+v\n want %+v", og.Authors, want)
+	}
+	if og.Author != "Jane Doe, John Smith" {
+		t.Errorf("Expected author names instead of profile URL, got %q", og.Author)
+	}
+}
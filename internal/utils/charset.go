@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gogs/chardet"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// metaCharsetPrescanSize is how much of the document is searched for a <meta> charset declaration
+const metaCharsetPrescanSize = 4096
+
+var (
+	// metaCharsetPattern matches <meta charset="..."> and <meta http-equiv="Content-Type" content="...; charset=...">
+	metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.\-]+)`)
+
+	// chardetLabels maps chardet charset names that differ from WHATWG labels
+	chardetLabels = map[string]string{
+		"GB-18030": "gb18030",
+	}
+)
+
+// detectedEncoding describes the character encoding of a fetched page
+type detectedEncoding struct {
+	encoding encoding.Encoding
+	name     string
+	source   string
+}
+
+// detectEncoding determines the encoding of an HTML body from its BOM, the Content-Type header,
+// a <meta> declaration and, failing those, content sniffing
+func detectEncoding(body []byte, contentType string) detectedEncoding {
+	// Byte order marks are authoritative
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return detectedEncoding{unicode.UTF8BOM, "utf-8", "bom"}
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		return detectedEncoding{unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), "utf-16be", "bom"}
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		return detectedEncoding{unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), "utf-16le", "bom"}
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if enc, name := lookupEncoding(params["charset"]); enc != nil && plausibleEncoding(body, name) {
+			return detectedEncoding{enc, name, "content_type"}
+		}
+	}
+
+	prescan := body
+	if len(prescan) > metaCharsetPrescanSize {
+		prescan = prescan[:metaCharsetPrescanSize]
+	}
+	if match := metaCharsetPattern.FindSubmatch(prescan); match != nil {
+		if enc, name := lookupEncoding(string(match[1])); enc != nil && plausibleEncoding(body, name) {
+			return detectedEncoding{enc, name, "meta"}
+		}
+	}
+
+	if mostlyUTF8(body) {
+		return detectedEncoding{encoding.Nop, "utf-8", "sniffed"}
+	}
+
+	if result, err := chardet.NewHtmlDetector().DetectBest(body); err == nil {
+		label := result.Charset
+		if mapped, ok := chardetLabels[label]; ok {
+			label = mapped
+		}
+		if enc, name := lookupEncoding(label); enc != nil {
+			return detectedEncoding{enc, name, "sniffed"}
+		}
+	}
+
+	// The HTML standard's fallback for undeclared, non-UTF-8 content
+	enc, name := lookupEncoding("windows-1252")
+	return detectedEncoding{enc, name, "default"}
+}
+
+// lookupEncoding resolves a charset label to an encoding using the WHATWG label table
+func lookupEncoding(label string) (encoding.Encoding, string) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, ""
+	}
+	enc, name := charset.Lookup(label)
+	if enc == nil {
+		return nil, ""
+	}
+	if name == "utf-8" {
+		return encoding.Nop, name
+	}
+	return enc, name
+}
+
+// plausibleEncoding rejects a declared UTF-8 charset when the body is largely not UTF-8, so
+// mislabelled legacy pages fall through to sniffing
+func plausibleEncoding(body []byte, name string) bool {
+	return name != "utf-8" || mostlyUTF8(body)
+}
+
+// mostlyUTF8 reports whether body is UTF-8, tolerating a few stray invalid bytes
+func mostlyUTF8(body []byte) bool {
+	nonASCII, invalid := 0, 0
+	for len(body) > 0 {
+		r, size := utf8.DecodeRune(body)
+		body = body[size:]
+		if r < utf8.RuneSelf {
+			continue
+		}
+		nonASCII++
+		if r == utf8.RuneError && size == 1 {
+			invalid++
+		}
+	}
+	return invalid*10 <= nonASCII
+}
+
+// decodeToUTF8 returns a reader that transcodes body from the detected encoding to UTF-8
+func decodeToUTF8(body []byte, detected detectedEncoding) io.Reader {
+	if detected.encoding == encoding.Nop {
+		return bytes.NewReader(body)
+	}
+	return transform.NewReader(bytes.NewReader(body), detected.encoding.NewDecoder())
+}
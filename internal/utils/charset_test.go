@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestFetchDocumentTranscodesToUTF8(t *testing.T) {
+	tests := []struct {
+		name         string
+		encoder      encoding.Encoding
+		contentType  string
+		metaTag      string
+		title        string
+		wantEncoding string
+	}{
+		{"Shift_JIS header", japanese.ShiftJIS, "text/html; charset=Shift_JIS", "", "日本語の記事タイトル", "shift_jis"},
+		{"GBK meta charset", simplifiedchinese.GBK, "text/html", `<meta charset="gbk">`, "中文文章标题", "gbk"},
+		{"Windows-1251 http-equiv", charmap.Windows1251, "text/html", `<meta http-equiv="Content-Type" content="text/html; charset=windows-1251">`, "Заголовок статьи", "windows-1251"},
+		{"ISO-8859-1 header", charmap.ISO8859_1, "text/html; charset=ISO-8859-1", "", "Café à la crème", "windows-1252"},
+		{"UTF-8 header", encoding.Nop, "text/html; charset=utf-8", "", "Überschrift", "utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := `<html><head>` + tt.metaTag + `<title>` + tt.title + `</title></head><body><p>` + tt.title + `</p></body></html>`
+			body, err := tt.encoder.NewEncoder().String(page)
+			if err != nil {
+				t.Fatalf("Failed to encode fixture: %v", err)
+			}
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(body))
+			}))
+			defer ts.Close()
+
+			ac, err := NewArticleCleaner()
+			if err != nil {
+				t.Fatalf("Failed to create ArticleCleaner: %v", err)
+			}
+			defer ac.Close()
+
+			fetched, err := ac.fetchDocument(ts.URL)
+			if err != nil {
+				t.Fatalf("fetchDocument failed: %v", err)
+			}
+
+			if title := fetched.doc.Find("title").Text(); title != tt.title {
+				t.Errorf("Expected title %q, got %q", tt.title, title)
+			}
+			if fetched.encoding != tt.wantEncoding {
+				t.Errorf("Expected encoding %q, got %q", tt.wantEncoding, fetched.encoding)
+			}
+		})
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	russian := strings.Repeat("Все люди рождаются свободными и равными в своем достоинстве и правах. ", 10)
+	windows1251, _ := charmap.Windows1251.NewEncoder().String("<html><body><p>" + russian + "</p></body></html>")
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		wantSource  string
+	}{
+		{"UTF-8 BOM", append([]byte{0xEF, 0xBB, 0xBF}, []byte("<p>hi</p>")...), "text/html; charset=windows-1252", "utf-8", "bom"},
+		{"Undeclared UTF-8", []byte("<p>Überschrift</p>"), "text/html", "utf-8", "sniffed"},
+		{"Undeclared Windows-1251", []byte(windows1251), "text/html", "windows-1251", "sniffed"},
+		{"Mislabelled UTF-8", []byte(windows1251), "text/html; charset=utf-8", "windows-1251", "sniffed"},
+	}
+
+	for _, tt := range tests {
+		detected := detectEncoding(tt.body, tt.contentType)
+		if detected.name != tt.want || detected.source != tt.wantSource {
+			t.Errorf("%s: got %s (%s), want %s (%s)", tt.name, detected.name, detected.source, tt.want, tt.wantSource)
+		}
+	}
+}
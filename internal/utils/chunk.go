@@ -0,0 +1,372 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunk size units
+const (
+	ChunkUnitCharacters = "characters"
+	ChunkUnitTokens     = "tokens"
+)
+
+// Default chunking settings, sized for common embedding models
+const (
+	defaultChunkTargetSize = 1000
+	defaultChunkOverlap    = 100
+)
+
+// ChunkOptions controls how markdown is split into chunks
+type ChunkOptions struct {
+	// TargetSize is the preferred maximum chunk size in Unit
+	TargetSize int `json:"target_size,omitempty"`
+	// Overlap is how much of the previous chunk is repeated at the start of the next one
+	Overlap int `json:"overlap,omitempty"`
+	// Unit is ChunkUnitCharacters or ChunkUnitTokens (approximate)
+	Unit string `json:"unit,omitempty"`
+}
+
+// DefaultChunkOptions returns chunks of about 1000 characters with 100 characters of overlap
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{
+		TargetSize: defaultChunkTargetSize,
+		Overlap:    defaultChunkOverlap,
+		Unit:       ChunkUnitCharacters,
+	}
+}
+
+// Chunk is a contiguous part of the markdown, Text being Markdown[Start:End] (byte offsets)
+type Chunk struct {
+	Index       int      `json:"index"`
+	Text        string   `json:"text"`
+	HeadingPath []string `json:"heading_path,omitempty"`
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Size        int      `json:"size"`
+}
+
+// markdownBlock is a heading, paragraph, list, fenced code block or table in the markdown
+type markdownBlock struct {
+	start, end  int
+	heading     int
+	atomic      bool
+	headingPath []string
+}
+
+// headingAnchorPattern matches the anchor lines placed before headings
+var headingAnchorPattern = regexp.MustCompile(`^<a id="[^"]*"></a>$`)
+
+// directionWrapperPattern matches the opening line of the block wrapMarkdownDirection adds
+var directionWrapperPattern = regexp.MustCompile(`^<div dir="[a-z]+">$`)
+
+// ChunkMarkdown splits markdown on heading and paragraph boundaries into chunks of about the
+// target size. Code blocks and tables are never split, and chunks never span two sections.
+func ChunkMarkdown(markdown string, options ChunkOptions) []Chunk {
+	if options.TargetSize <= 0 {
+		options.TargetSize = defaultChunkTargetSize
+	}
+	if options.Overlap < 0 || options.Overlap >= options.TargetSize {
+		options.Overlap = 0
+	}
+	measure := chunkMeasure(options.Unit)
+
+	parsed := parseMarkdownBlocks(markdown)
+	// The dir="rtl" wrapper of right-to-left markdown is not content of any chunk
+	if n := len(parsed); n >= 2 && directionWrapperPattern.MatchString(strings.TrimSpace(markdown[parsed[0].start:parsed[0].end])) &&
+		strings.TrimSpace(markdown[parsed[n-1].start:parsed[n-1].end]) == "</div>" {
+		parsed = parsed[1 : n-1]
+	}
+
+	var blocks []markdownBlock
+	for _, block := range parsed {
+		if !block.atomic && block.heading == 0 && measure(markdown[block.start:block.end]) > options.TargetSize {
+			blocks = append(blocks, splitMarkdownBlock(markdown, block, options.TargetSize, measure)...)
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	var chunks []Chunk
+	var current []markdownBlock
+	overlapStart := -1
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		start, end := current[0].start, current[len(current)-1].end
+		if overlapStart >= 0 && overlapStart < start {
+			start = overlapStart
+		}
+		text := markdown[start:end]
+		chunks = append(chunks, Chunk{
+			Index:       len(chunks),
+			Text:        text,
+			HeadingPath: current[len(current)-1].headingPath,
+			Start:       start,
+			End:         end,
+			Size:        measure(text),
+		})
+		current = nil
+		overlapStart = -1
+	}
+
+	for _, block := range blocks {
+		onlyHeadings := true
+		for _, b := range current {
+			if b.heading == 0 {
+				onlyHeadings = false
+			}
+		}
+
+		if block.heading > 0 && !onlyHeadings {
+			// A new section always starts a new chunk
+			flush()
+		} else if len(current) > 0 && !onlyHeadings && measure(markdown[current[0].start:block.end]) > options.TargetSize {
+			previous := current
+			flush()
+			if options.Overlap > 0 {
+				overlapStart = chunkOverlapStart(markdown, previous, options.Overlap, measure)
+			}
+		}
+		current = append(current, block)
+	}
+	flush()
+
+	return chunks
+}
+
+// parseMarkdownBlocks splits markdown into blocks separated by blank lines, keeping fenced code
+// blocks and tables whole and tracking the heading path of every block
+func parseMarkdownBlocks(markdown string) []markdownBlock {
+	var blocks []markdownBlock
+	var path []string
+	var pathLevels []int
+
+	offset := 0
+	blockStart := -1
+	blockEnd := 0
+	fence := ""
+	table := false
+
+	emit := func(heading, level int, atomic bool, text string) {
+		if heading > 0 {
+			for len(pathLevels) > 0 && pathLevels[len(pathLevels)-1] >= level {
+				pathLevels = pathLevels[:len(pathLevels)-1]
+				path = path[:len(path)-1]
+			}
+			pathLevels = append(pathLevels, level)
+			path = append(path, text)
+		}
+		blocks = append(blocks, markdownBlock{
+			start:       blockStart,
+			end:         blockEnd,
+			heading:     heading,
+			atomic:      atomic,
+			headingPath: append([]string(nil), path...),
+		})
+		blockStart = -1
+		table = false
+	}
+
+	for offset < len(markdown) {
+		lineEnd := strings.IndexByte(markdown[offset:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(markdown)
+		} else {
+			lineEnd += offset
+		}
+		line := markdown[offset:lineEnd]
+		trimmed := strings.TrimSpace(line)
+		next := lineEnd + 1
+
+		switch {
+		case fence != "":
+			// Inside a fenced code block: only the closing fence ends it
+			blockEnd = lineEnd
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+				emit(0, 0, true, "")
+			}
+		case trimmed == "":
+			if blockStart >= 0 {
+				emit(0, 0, table, "")
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			if blockStart >= 0 {
+				emit(0, 0, table, "")
+			}
+			blockStart, blockEnd = offset, lineEnd
+			fence = trimmed[:3]
+		case headingAnchorPattern.MatchString(trimmed):
+			// Anchors belong to the heading that follows them
+			if blockStart >= 0 {
+				emit(0, 0, table, "")
+			}
+			blockStart, blockEnd = offset, lineEnd
+		case markdownHeadingPattern.MatchString(trimmed) && (blockStart < 0 || headingAnchorPattern.MatchString(strings.TrimSpace(markdown[blockStart:blockEnd]))):
+			if blockStart < 0 {
+				blockStart = offset
+			}
+			blockEnd = lineEnd
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			emit(1, level, false, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+		case setextUnderlinePattern.MatchString(trimmed) && blockStart >= 0 && !table && !strings.Contains(markdown[blockStart:blockEnd], "\n"):
+			// A single line underlined with = or - is a setext heading
+			text := strings.TrimSpace(markdown[blockStart:blockEnd])
+			blockEnd = lineEnd
+			level := 2
+			if strings.HasPrefix(trimmed, "=") {
+				level = 1
+			}
+			emit(1, level, false, text)
+		default:
+			if blockStart < 0 {
+				blockStart = offset
+				table = strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "<table")
+			}
+			blockEnd = lineEnd
+		}
+
+		offset = next
+	}
+	if blockStart >= 0 {
+		emit(0, 0, fence != "" || table, "")
+	}
+
+	// Anchor lines separated from their heading by a blank line are merged into it
+	merged := blocks[:0]
+	for i, block := range blocks {
+		if i+1 < len(blocks) && blocks[i+1].heading > 0 && headingAnchorPattern.MatchString(strings.TrimSpace(markdown[block.start:block.end])) {
+			blocks[i+1].start = block.start
+			continue
+		}
+		merged = append(merged, block)
+	}
+	return merged
+}
+
+// splitMarkdownBlock splits an oversized paragraph at sentence ends, then at whitespace, into
+// pieces no larger than the target size
+func splitMarkdownBlock(markdown string, block markdownBlock, targetSize int, measure func(string) int) []markdownBlock {
+	var pieces []markdownBlock
+	start := block.start
+	for start < block.end {
+		// Skip whitespace between pieces
+		for start < block.end && (markdown[start] == ' ' || markdown[start] == '\n') {
+			start++
+		}
+		if start >= block.end {
+			break
+		}
+		if measure(markdown[start:block.end]) <= targetSize {
+			pieces = append(pieces, markdownBlock{start: start, end: block.end, headingPath: block.headingPath})
+			break
+		}
+
+		end := lastBoundaryWithin(markdown, start, block.end, targetSize, measure, isSentenceBoundary)
+		if end <= start {
+			end = lastBoundaryWithin(markdown, start, block.end, targetSize, measure, isWordBoundary)
+		}
+		if end <= start {
+			// A single word longer than the target: cut at a rune boundary
+			end = start
+			for end < block.end && measure(markdown[start:end]) < targetSize {
+				_, size := utf8.DecodeRuneInString(markdown[end:])
+				end += size
+			}
+		}
+		pieces = append(pieces, markdownBlock{start: start, end: end, headingPath: block.headingPath})
+		start = end
+	}
+	return pieces
+}
+
+// lastBoundaryWithin returns the furthest boundary after start whose prefix fits the target size
+func lastBoundaryWithin(markdown string, start, end, targetSize int, measure func(string) int, boundary func(string, int) bool) int {
+	best := -1
+	for i := start + 1; i < end; i++ {
+		if !boundary(markdown, i) {
+			continue
+		}
+		if measure(markdown[start:i]) > targetSize {
+			break
+		}
+		best = i
+	}
+	return best
+}
+
+// isSentenceBoundary reports whether a sentence ends just before position i
+func isSentenceBoundary(text string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	if isCJKTerminator(r) {
+		return true
+	}
+	next, _ := utf8.DecodeRuneInString(text[i:])
+	return isSentenceTerminator(r) && unicode.IsSpace(next)
+}
+
+// isWordBoundary reports whether position i is at whitespace following a word
+func isWordBoundary(text string, i int) bool {
+	next, _ := utf8.DecodeRuneInString(text[i:])
+	previous, _ := utf8.DecodeLastRuneInString(text[:i])
+	return unicode.IsSpace(next) && !unicode.IsSpace(previous)
+}
+
+// chunkOverlapStart returns where the overlap repeated from the previous chunk begins: the
+// first word boundary leaving at most overlap, never inside a code block or table
+func chunkOverlapStart(markdown string, previous []markdownBlock, overlap int, measure func(string) int) int {
+	end := previous[len(previous)-1].end
+	for i := len(previous) - 1; i >= 0; i-- {
+		block := previous[i]
+		if measure(markdown[block.start:end]) <= overlap {
+			if block.atomic || block.heading > 0 || i == 0 {
+				return block.start
+			}
+			continue
+		}
+		if block.atomic || block.heading > 0 {
+			// Never start inside a block that cannot be split
+			if block.end == end {
+				return -1
+			}
+			return previous[i+1].start
+		}
+		for pos := block.start + 1; pos < block.end; pos++ {
+			if isWordBoundary(markdown, pos) && measure(strings.TrimLeft(markdown[pos:end], " \n")) <= overlap {
+				return pos + len(markdown[pos:end]) - len(strings.TrimLeft(markdown[pos:end], " \n"))
+			}
+		}
+		if block.end == end {
+			return -1
+		}
+		return previous[i+1].start
+	}
+	return -1
+}
+
+// chunkMeasure returns the size function for a chunk unit
+func chunkMeasure(unit string) func(string) int {
+	if unit == ChunkUnitTokens {
+		return approximateTokens
+	}
+	return utf8.RuneCountInString
+}
+
+// approximateTokens estimates the token count of text: about four characters per token for
+// alphabetic scripts and one token per CJK character
+func approximateTokens(text string) int {
+	other, cjk := 0, 0
+	for _, r := range text {
+		if isCJK(r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const chunkTestMarkdown = `<a id="intro"></a>
+
+# Intro
+
+First paragraph of the introduction.
+
+Second paragraph of the introduction.
+
+<a id="setup"></a>
+
+## Setup
+
+` + "```go\nfunc main() {\n\n\tfmt.Println(\"hello\")\n}\n```" + `
+
+| Name | Value |
+| --- | --- |
+| a | 1 |
+
+<a id="usage"></a>
+
+## Usage
+
+Run the tool. Then read the output carefully. Finally, write the report and send it.`
+
+func TestChunkMarkdownSections(t *testing.T) {
+	chunks := ChunkMarkdown(chunkTestMarkdown, ChunkOptions{TargetSize: 1000})
+
+	wantPaths := [][]string{{"Intro"}, {"Intro", "Setup"}, {"Intro", "Usage"}}
+	if len(chunks) != len(wantPaths) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(wantPaths), len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Index != i {
+			t.Errorf("chunks[%d].Index = %d", i, chunk.Index)
+		}
+		if !reflect.DeepEqual(chunk.HeadingPath, wantPaths[i]) {
+			t.Errorf("chunks[%d].HeadingPath = %v, want %v", i, chunk.HeadingPath, wantPaths[i])
+		}
+		if chunk.Text != chunkTestMarkdown[chunk.Start:chunk.End] {
+			t.Errorf("chunks[%d].Text does not match its offsets", i)
+		}
+	}
+	if !strings.HasPrefix(chunks[1].Text, `<a id="setup"></a>`) {
+		t.Errorf("Expected the heading anchor to start the section chunk, got %q", chunks[1].Text)
+	}
+}
+
+func TestChunkMarkdownKeepsCodeAndTables(t *testing.T) {
+	chunks := ChunkMarkdown(chunkTestMarkdown, ChunkOptions{TargetSize: 30})
+
+	code := "```go\nfunc main() {\n\n\tfmt.Println(\"hello\")\n}\n```"
+	table := "| Name | Value |\n| --- | --- |\n| a | 1 |"
+	for _, chunk := range chunks {
+		if strings.Contains(chunk.Text, "```") && !strings.Contains(chunk.Text, code) {
+			t.Errorf("Chunk contains part of a code block: %q", chunk.Text)
+		}
+		if strings.Contains(chunk.Text, "| ---") && !strings.Contains(chunk.Text, table) {
+			t.Errorf("Chunk contains part of a table: %q", chunk.Text)
+		}
+	}
+
+	htmlTable := "<table>\n<tr><th colspan=\"2\">Merged heading cell</th></tr>\n<tr><td>a</td><td>b</td></tr>\n</table>"
+	chunks = ChunkMarkdown("Intro text.\n\n"+htmlTable+"\n\nOutro text.", ChunkOptions{TargetSize: 20})
+	if !slices.ContainsFunc(chunks, func(chunk Chunk) bool { return chunk.Text == htmlTable }) {
+		t.Errorf("Expected the HTML table to stay in one chunk, got %+v", chunks)
+	}
+}
+
+func TestChunkMarkdownSplitsLongParagraphs(t *testing.T) {
+	markdown := "Run the tool. Then read the output carefully. Finally, write the report and send it."
+	chunks := ChunkMarkdown(markdown, ChunkOptions{TargetSize: 50})
+
+	want := []string{"Run the tool. Then read the output carefully.", "Finally, write the report and send it."}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %+v", len(want), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Text != want[i] {
+			t.Errorf("chunks[%d].Text = %q, want %q", i, chunk.Text, want[i])
+		}
+		if chunk.Size > 50 {
+			t.Errorf("chunks[%d].Size = %d, exceeds the target", i, chunk.Size)
+		}
+	}
+
+	// A single word longer than the target is cut at rune boundaries
+	chunks = ChunkMarkdown(strings.Repeat("é", 25), ChunkOptions{TargetSize: 10})
+	if len(chunks) != 3 || chunks[0].Text != strings.Repeat("é", 10) || chunks[2].Text != strings.Repeat("é", 5) {
+		t.Errorf("Expected rune-aligned pieces of 10, 10 and 5, got %+v", chunks)
+	}
+}
+
+func TestChunkMarkdownOverlap(t *testing.T) {
+	markdown := "## Notes\n\nalpha beta gamma delta.\n\nepsilon zeta eta theta.\n\niota kappa lambda mu.\n\n## Next\n\nnu xi omicron."
+	chunks := ChunkMarkdown(markdown, ChunkOptions{TargetSize: 50, Overlap: 12})
+
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %+v", chunks)
+	}
+	if !strings.HasPrefix(chunks[1].Text, "gamma delta.\n\nepsilon") {
+		t.Errorf("Expected the second chunk to repeat the tail of the first, got %q", chunks[1].Text)
+	}
+	if chunks[1].Start >= chunks[0].End {
+		t.Errorf("Expected overlapping offsets, got %d..%d and %d..%d", chunks[0].Start, chunks[0].End, chunks[1].Start, chunks[1].End)
+	}
+	if !strings.HasPrefix(chunks[2].Text, "## Next") {
+		t.Errorf("Expected no overlap across sections, got %q", chunks[2].Text)
+	}
+	for i, chunk := range chunks {
+		if chunk.Text != markdown[chunk.Start:chunk.End] {
+			t.Errorf("chunks[%d].Text does not match its offsets", i)
+		}
+	}
+}
+
+func TestApproximateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"hello world", 3},
+		{"日本語", 3},
+		{"Go 言語", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := approximateTokens(tt.text); got != tt.want {
+				t.Errorf("approximateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanArticleChunks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>Chunked Guide</title></head>
+<body>
+	<article>
+		<h2>Installation</h2>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<h2>Configuration</h2>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	options := DefaultArticleOptions()
+	chunkOptions := DefaultChunkOptions()
+	options.Chunking = &chunkOptions
+	article, err := ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+
+	if len(article.Chunks) != 2 {
+		t.Fatalf("Expected one chunk per section, got %+v", article.Chunks)
+	}
+	for i, want := range []string{"Installation", "Configuration"} {
+		chunk := article.Chunks[i]
+		if !reflect.DeepEqual(chunk.HeadingPath, []string{want}) {
+			t.Errorf("Chunks[%d].HeadingPath = %v, want [%s]", i, chunk.HeadingPath, want)
+		}
+		if chunk.Text != article.Markdown[chunk.Start:chunk.End] {
+			t.Errorf("Chunks[%d] offsets do not match the markdown", i)
+		}
+	}
+}
+
+func TestChunkMarkdownSetextHeadings(t *testing.T) {
+	markdown := "Intro\n=====\n\nSome text.\n\nUsage\n-----\n\nMore text.\n\n| a |\n| --- |"
+	chunks := ChunkMarkdown(markdown, ChunkOptions{TargetSize: 20})
+
+	wantPaths := [][]string{{"Intro"}, {"Intro", "Usage"}, {"Intro", "Usage"}}
+	if len(chunks) != len(wantPaths) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(wantPaths), len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if !reflect.DeepEqual(chunk.HeadingPath, wantPaths[i]) {
+			t.Errorf("chunks[%d].HeadingPath = %v, want %v", i, chunk.HeadingPath, wantPaths[i])
+		}
+	}
+}
+
+func TestChunkMarkdownRightToLeft(t *testing.T) {
+	markdown := wrapMarkdownDirection("# T\n\nhello world, this is the first section.\n\n## U\n\nmore text", "rtl")
+	chunks := ChunkMarkdown(markdown, DefaultChunkOptions())
+
+	want := []string{"# T\n\nhello world, this is the first section.", "## U\n\nmore text"}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Text != want[i] {
+			t.Errorf("chunks[%d].Text = %q, want %q", i, chunk.Text, want[i])
+		}
+		if chunk.Text != markdown[chunk.Start:chunk.End] {
+			t.Errorf("chunks[%d].Text does not match its offsets", i)
+		}
+	}
+}
@@ -1,17 +1,16 @@
 package utils
 
 import (
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
-	"strconv"
+	"slices"
 	"strings"
-	"time"
 
 	"page-zen/internal/logger"
 
-	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/go-shiori/go-readability"
 	"go.uber.org/zap"
@@ -35,25 +34,68 @@ type OpenGraphData struct {
 	TwitterImage       string `json:"twitter_image,omitempty"`
 	// Additional metadata
 	Author      string   `json:"author,omitempty"`
+	Authors     []Author `json:"authors,omitempty"`
 	PublishedAt string   `json:"published_at,omitempty"`
 	ModifiedAt  string   `json:"modified_at,omitempty"`
 	Section     string   `json:"section,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
+	// Keywords from <meta name="keywords">, news_keywords and JSON-LD
+	Keywords []string `json:"keywords,omitempty"`
+	// Document-level links (canonical, AMP, alternates, feeds, pagination)
+	Links *PageLinks `json:"links,omitempty"`
+	// oEmbed data from discovery or the built-in provider registry
+	OEmbed *OEmbedData `json:"oembed,omitempty"`
+	// Normalized dates with their source and confidence
+	Dates *ArticleDates `json:"dates,omitempty"`
 }
 
 // CleanedArticle represents a cleaned article with both text and markdown content
 type CleanedArticle struct {
-	Title       string         `json:"title"`
-	Content     string         `json:"content"`
-	Markdown    string         `json:"markdown"`
-	URL         string         `json:"url"`
-	Author      string         `json:"author,omitempty"`
-	Excerpt     string         `json:"excerpt,omitempty"`
-	Length      int            `json:"length"`
-	PublishedAt string         `json:"published_at,omitempty"`
-	OpenGraph   *OpenGraphData `json:"open_graph,omitempty"`
+	Title       string        `json:"title"`
+	Content     string        `json:"content"`
+	Markdown    string        `json:"markdown"`
+	URL         string        `json:"url"`
+	Author      string        `json:"author,omitempty"`
+	Authors     []Author      `json:"authors,omitempty"`
+	Excerpt     string        `json:"excerpt,omitempty"`
+	Summary     []string      `json:"summary,omitempty"`
+	Length      int           `json:"length"`
+	PublishedAt string        `json:"published_at,omitempty"`
+	ModifiedAt  string        `json:"modified_at,omitempty"`
+	Dates       *ArticleDates `json:"dates,omitempty"`
+	// Detected BCP-47 language and text direction
+	Language           string  `json:"language,omitempty"`
+	LanguageConfidence float64 `json:"language_confidence,omitempty"`
+	Dir                string  `json:"dir,omitempty"`
+	// Word count, reading time and readability of the cleaned text
+	Stats *ArticleStats `json:"stats,omitempty"`
+	// Heading outline of the article
+	TOC []TOCEntry `json:"toc,omitempty"`
+	// Images kept in the article, with the lead image marked
+	Images []ArticleImage `json:"images,omitempty"`
+	// Embedded videos, posts and snippets, kept in the markdown as links
+	Embeds []Embed `json:"embeds,omitempty"`
+	// Hyperlinks in the cleaned content
+	Links []ArticleLink `json:"links,omitempty"`
+	// Footnotes and references, rendered as [^label] definitions at the end of the markdown
+	Footnotes []Footnote `json:"footnotes,omitempty"`
+	// Ranked key phrases, when requested
+	Keywords []Keyword `json:"keywords,omitempty"`
+	// Markdown split into chunks for retrieval, when requested
+	Chunks []Chunk `json:"chunks,omitempty"`
+	// Character encoding the page was transcoded from
+	Encoding  string         `json:"encoding,omitempty"`
+	OpenGraph *OpenGraphData `json:"open_graph,omitempty"`
+
+	// html is the cleaned article HTML the markdown was converted from
+	html string
+	// headings is the flat heading list the markdown anchors were placed for
+	headings []TOCEntry
 }
 
+// userAgent identifies PageZen to the sites it fetches
+const userAgent = "Mozilla/5.0 (compatible; PageZen/1.0; +https://github.com/Rohithgilla12/page-zen)"
+
 // ArticleCleaner handles the cleaning and processing of web articles
 type ArticleCleaner struct {
 	logger *zap.SugaredLogger
@@ -152,74 +194,68 @@ func (ac *ArticleCleaner) cleanTextContent(content string) string {
 	return strings.TrimSpace(content)
 }
 
-// generateExcerpt creates a brief excerpt from the content
-func (ac *ArticleCleaner) generateExcerpt(content string, maxLength int) string {
-	if len(content) <= maxLength {
-		return content
-	}
-
-	// Find the last space before maxLength to avoid cutting words
-	excerpt := content[:maxLength]
-	if lastSpace := strings.LastIndex(excerpt, " "); lastSpace > 0 && lastSpace > maxLength-50 {
-		excerpt = excerpt[:lastSpace]
-	}
-
-	return excerpt + "..."
-}
-
-// resolveURL converts relative URLs to absolute URLs
+// resolveURL converts relative URLs to absolute URLs following RFC 3986
 func (ac *ArticleCleaner) resolveURL(rawURL string, baseURL *url.URL) string {
+	rawURL = strings.TrimSpace(rawURL)
 	if rawURL == "" {
 		return ""
 	}
 
-	// Already absolute URL
-	if strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://") {
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		ac.logger.Debugw("Failed to parse URL, leaving it unresolved", "url", rawURL, "error", err)
 		return rawURL
 	}
-
-	// Protocol-relative URL
-	if strings.HasPrefix(rawURL, "//") {
-		return "https:" + rawURL
+	if ref.IsAbs() {
+		return rawURL
 	}
-
-	// Absolute path
-	if strings.HasPrefix(rawURL, "/") {
-		return baseURL.Scheme + "://" + baseURL.Host + rawURL
+	if baseURL == nil {
+		return ref.String()
 	}
 
-	// Relative path
-	return baseURL.Scheme + "://" + baseURL.Host + "/" + rawURL
+	return baseURL.ResolveReference(ref).String()
 }
 
-// processImages handles both picture elements and standalone img elements
-func (ac *ArticleCleaner) processImages(doc *goquery.Document, baseURL *url.URL) {
-	ac.processPictureElements(doc, baseURL)
-	ac.processImgElements(doc, baseURL)
+// processImages handles both picture elements and standalone img elements. It returns the
+// srcset selections made, keyed by the resolved URL of the chosen image.
+func (ac *ArticleCleaner) processImages(doc *goquery.Document, baseURL *url.URL, formats []string) map[string]*imageSelection {
+	selections := make(map[string]*imageSelection)
+	ac.promoteLazyImages(doc)
+	ac.processPictureElements(doc, baseURL, formats, selections)
+	ac.processImgElements(doc, baseURL, formats, selections)
+	ac.removePlaceholderImages(doc)
+	return selections
 }
 
 // processPictureElements handles picture elements and converts them to img elements
-func (ac *ArticleCleaner) processPictureElements(doc *goquery.Document, baseURL *url.URL) {
+func (ac *ArticleCleaner) processPictureElements(doc *goquery.Document, baseURL *url.URL, formats []string, selections map[string]*imageSelection) {
 	pictureCount := 0
 	doc.Find("picture").Each(func(i int, picture *goquery.Selection) {
 		pictureCount++
-		img := picture.Find("img")
+		img := picture.Find("img").First()
 		if img.Length() == 0 {
 			return
 		}
 
-		// Find the highest quality source
-		if highestQualitySource := ac.findHighestQualitySource(picture); highestQualitySource != "" {
-			// Replace webp with png for better compatibility
-			highestQualitySource = strings.Replace(highestQualitySource, "webp", "png", 1)
-			resolvedURL := ac.resolveURL(highestQualitySource, baseURL)
-			img.SetAttr("src", resolvedURL)
-			ac.logger.Debugw("Updated picture element source", "new_src", resolvedURL)
+		// Gather every candidate offered by the sources and the fallback img
+		var candidates []ImageCandidate
+		picture.Find("source").Each(func(j int, source *goquery.Selection) {
+			for _, candidate := range parseSrcset(source.AttrOr("srcset", "")) {
+				candidate.Type = strings.TrimSpace(source.AttrOr("type", ""))
+				candidate.Media = strings.TrimSpace(source.AttrOr("media", ""))
+				candidates = append(candidates, candidate)
+			}
+		})
+		candidates = append(candidates, imgCandidates(img)...)
+
+		// The img usually carries the sizes, but it may only be given on the sources
+		if _, exists := img.Attr("sizes"); !exists {
+			if sizes, exists := picture.Find("source[sizes]").First().Attr("sizes"); exists {
+				img.SetAttr("sizes", sizes)
+			}
 		}
 
-		// Clean up attributes
-		img.RemoveAttr("loading")
-		img.RemoveAttr("decoding")
+		ac.applyImageCandidate(img, candidates, formats, baseURL, selections)
 
 		// Replace picture with the img element
 		picture.ReplaceWithSelection(img)
@@ -230,62 +266,27 @@ func (ac *ArticleCleaner) processPictureElements(doc *goquery.Document, baseURL
 	}
 }
 
-// findHighestQualitySource finds the highest quality source from picture element
-func (ac *ArticleCleaner) findHighestQualitySource(picture *goquery.Selection) string {
-	highestQualitySource := ""
-	maxWidth := 0
-
-	picture.Find("source").Each(func(i int, source *goquery.Selection) {
-		srcset, exists := source.Attr("srcset")
-		if !exists {
-			return
-		}
-
-		sources := strings.Split(srcset, ",")
-		for _, src := range sources {
-			parts := strings.Fields(strings.TrimSpace(src))
-			if len(parts) != 2 {
-				continue
-			}
-
-			// Parse the width value (remove 'w' suffix)
-			widthStr := strings.TrimSuffix(parts[1], "w")
-			width, err := strconv.Atoi(widthStr)
-			if err != nil {
-				ac.logger.Debugw("Failed to parse width from srcset", "width_string", widthStr, "error", err)
-				continue
-			}
-
-			// Keep track of highest quality source
-			if width > maxWidth {
-				maxWidth = width
-				highestQualitySource = parts[0]
-			}
-		}
-	})
-
-	return highestQualitySource
-}
-
 // processImgElements handles standalone img elements
-func (ac *ArticleCleaner) processImgElements(doc *goquery.Document, baseURL *url.URL) {
+func (ac *ArticleCleaner) processImgElements(doc *goquery.Document, baseURL *url.URL, formats []string, selections map[string]*imageSelection) {
 	imgCount := 0
 	doc.Find("img").Each(func(i int, img *goquery.Selection) {
 		imgCount++
 
-		// Remove attributes that might cause issues
-		img.RemoveAttr("loading")
-		img.RemoveAttr("decoding")
-		img.RemoveAttr("srcset")
-
-		// Handle relative URLs for src attribute
-		if src, exists := img.Attr("src"); exists {
+		// Choose from the srcset when one is offered, otherwise just resolve src
+		if _, exists := img.Attr("srcset"); exists {
+			ac.applyImageCandidate(img, imgCandidates(img), formats, baseURL, selections)
+		} else if src, exists := img.Attr("src"); exists {
+			// Handle relative URLs for src attribute
 			resolvedURL := ac.resolveURL(src, baseURL)
 			if resolvedURL != src {
 				img.SetAttr("src", resolvedURL)
 				ac.logger.Debugw("Updated img src", "original", src, "new", resolvedURL)
 			}
 		}
+
+		// Remove attributes that might cause issues
+		img.RemoveAttr("loading")
+		img.RemoveAttr("decoding")
 	})
 
 	if imgCount > 0 {
@@ -293,8 +294,47 @@ func (ac *ArticleCleaner) processImgElements(doc *goquery.Document, baseURL *url
 	}
 }
 
-// extractOpenGraphData extracts Open Graph and Twitter Card metadata from HTML document
-func (ac *ArticleCleaner) extractOpenGraphData(doc *goquery.Document, pageURL string, baseURL *url.URL) *OpenGraphData {
+// applyImageCandidate sets the best candidate as the img src and records the selection
+func (ac *ArticleCleaner) applyImageCandidate(img *goquery.Selection, candidates []ImageCandidate, formats []string, baseURL *url.URL, selections map[string]*imageSelection) {
+	// Density candidates are sized by the slot the sizes attribute describes, or the img width
+	widthHint := parseSizes(img.AttrOr("sizes", ""))
+	if widthHint == 0 {
+		widthHint = imageDimension(img.AttrOr("width", ""))
+	}
+	img.RemoveAttr("srcset")
+	img.RemoveAttr("sizes")
+
+	chosen, ok := selectImageCandidate(candidates, formats, widthHint)
+	if !ok {
+		return
+	}
+
+	resolvedURL := ac.resolveURL(chosen.URL, baseURL)
+	img.SetAttr("src", resolvedURL)
+	ac.logger.Debugw("Selected image candidate",
+		"src", resolvedURL,
+		"format", imageFormat(chosen),
+		"candidates", len(candidates),
+	)
+
+	selection, exists := selections[resolvedURL]
+	if !exists {
+		chosen.URL = resolvedURL
+		selection = &imageSelection{chosen: chosen}
+		selections[resolvedURL] = selection
+	}
+	for _, candidate := range candidates {
+		candidate.URL = ac.resolveURL(candidate.URL, baseURL)
+		if candidate.URL == resolvedURL || containsImageCandidate(selection.alternatives, candidate.URL) {
+			continue
+		}
+		selection.alternatives = append(selection.alternatives, candidate)
+	}
+}
+
+// extractOpenGraphData extracts Open Graph and Twitter Card metadata from HTML document. The
+// og:url is cleaned with the given tracking parameter rules.
+func (ac *ArticleCleaner) extractOpenGraphData(doc *goquery.Document, pageURL string, baseURL *url.URL, trackingParams []string) *OpenGraphData {
 	og := &OpenGraphData{URL: pageURL}
 
 	// Extract Open Graph meta tags
@@ -306,9 +346,45 @@ func (ac *ArticleCleaner) extractOpenGraphData(doc *goquery.Document, pageURL st
 	// Fallback to standard meta tags if Open Graph is not available
 	ac.extractFallbackMetaTags(doc, og)
 
+	// Extract JSON-LD structured data
+	jsonLD := ac.extractJSONLD(doc)
+
+	// Relative URLs resolve against <base href> when the page declares one
+	documentBase := documentBaseURL(doc, baseURL)
+
+	// Extract structured authors
+	og.Authors = ac.extractAuthors(doc, jsonLD, documentBase)
+	if (og.Author == "" || isAbsoluteHTTPURL(og.Author)) && len(og.Authors) > 0 {
+		if names := authorNames(og.Authors); names != "" {
+			og.Author = names
+		}
+	}
+
+	// Normalize published and modified dates from every source
+	og.Dates = ac.extractArticleDates(doc, jsonLD, og, baseURL)
+	if og.Dates != nil && og.Dates.Published != nil {
+		og.PublishedAt = og.Dates.Published.Value
+	}
+	if og.Dates != nil && og.Dates.Modified != nil {
+		og.ModifiedAt = og.Dates.Modified.Value
+	}
+
+	// Extract declared keywords
+	og.Keywords = extractKeywordMetadata(doc, jsonLD)
+
+	// Extract canonical, alternate and feed links
+	og.Links = ac.extractPageLinks(doc, documentBase)
+
+	// Discover and fetch oEmbed data
+	og.OEmbed = ac.extractOEmbedData(doc, baseURL)
+	if og.OEmbed != nil && og.Image == "" {
+		og.Image = og.OEmbed.ThumbnailURL
+	}
+
 	// Resolve relative URLs
-	og.Image = ac.resolveURL(og.Image, baseURL)
-	og.TwitterImage = ac.resolveURL(og.TwitterImage, baseURL)
+	og.URL = CleanURL(ac.resolveURL(og.URL, documentBase), trackingParams)
+	og.Image = ac.resolveURL(og.Image, documentBase)
+	og.TwitterImage = ac.resolveURL(og.TwitterImage, documentBase)
 
 	ac.logger.Infow("Extracted Open Graph data",
 		"title", og.Title,
@@ -350,7 +426,10 @@ func (ac *ArticleCleaner) extractOGMetaTags(doc *goquery.Document, og *OpenGraph
 		case "og:locale":
 			og.Locale = content
 		case "article:author":
-			og.Author = content
+			// Prefer a name over a profile URL when both are given
+			if og.Author == "" || (isAbsoluteHTTPURL(og.Author) && !isAbsoluteHTTPURL(content)) {
+				og.Author = content
+			}
 		case "article:published_time":
 			og.PublishedAt = content
 		case "article:modified_time":
@@ -414,45 +493,83 @@ func (ac *ArticleCleaner) extractFallbackMetaTags(doc *goquery.Document, og *Ope
 	}
 }
 
+// fetchedDocument is a parsed page together with the response details used during extraction
+type fetchedDocument struct {
+	doc      *goquery.Document
+	baseURL  *url.URL
+	header   http.Header
+	encoding string
+}
+
 // fetchAndParseDocument fetches a URL and returns a parsed goquery document
 func (ac *ArticleCleaner) fetchAndParseDocument(pageURL string) (*goquery.Document, *url.URL, error) {
+	fetched, err := ac.fetchDocument(pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fetched.doc, fetched.baseURL, nil
+}
+
+// fetchDocument fetches a URL and returns the parsed document with its final URL and response headers
+func (ac *ArticleCleaner) fetchDocument(pageURL string) (*fetchedDocument, error) {
 	ac.logger.Infow("Starting to fetch and parse article", "url", pageURL)
 
 	req, err := http.NewRequest("GET", pageURL, nil)
 	if err != nil {
 		ac.logger.Errorw("Failed to create request", "url", pageURL, "error", err)
-		return nil, nil, err
+		return nil, err
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; PageZen/1.0; +https://github.com/Rohithgilla12/page-zen)")
+	req.Header.Set("User-Agent", userAgent)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		ac.logger.Errorw("Failed to fetch URL", "url", pageURL, "error", err)
-		return nil, nil, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	ac.logger.Infow("Successfully fetched URL", "url", pageURL, "status_code", resp.StatusCode)
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		ac.logger.Errorw("Failed to read response body", "url", pageURL, "error", err)
+		return nil, err
+	}
+
+	// Transcode to UTF-8 before parsing
+	detected := detectEncoding(body, resp.Header.Get("Content-Type"))
+	ac.logger.Debugw("Detected document encoding", "url", pageURL, "encoding", detected.name, "source", detected.source)
+
+	doc, err := goquery.NewDocumentFromReader(decodeToUTF8(body, detected))
 	if err != nil {
 		ac.logger.Errorw("Failed to parse HTML document", "url", pageURL, "error", err)
-		return nil, nil, err
+		return nil, err
 	}
 
-	return doc, resp.Request.URL, nil
+	return &fetchedDocument{doc: doc, baseURL: resp.Request.URL, header: resp.Header, encoding: detected.name}, nil
 }
 
-// convertToMarkdown converts HTML content to markdown
-func (ac *ArticleCleaner) convertToMarkdown(htmlContent string) string {
-	ac.logger.Info("Converting HTML content to markdown")
-	converter := md.NewConverter("", true, nil)
+// convertToMarkdown converts HTML content to markdown with the given options
+func (ac *ArticleCleaner) convertToMarkdown(htmlContent string, options MarkdownOptions) string {
+	ac.logger.Infow("Converting HTML content to markdown", "flavor", options.Flavor)
+	converter := ac.markdownConverter(options)
 	markdown, err := converter.ConvertString(htmlContent)
 	if err != nil {
 		ac.logger.Warnw("Failed to convert to markdown, using HTML content", "error", err)
 		return htmlContent // Fallback to HTML if markdown conversion fails
 	}
-	return markdown
+	return wrapMarkdown(markdown, options.WrapWidth)
+}
+
+// renderArticleMarkdown converts article HTML to markdown with heading anchors, footnote
+// definitions and, when requested, a table of contents, wrapped in the text direction
+func (ac *ArticleCleaner) renderArticleMarkdown(content string, headings []TOCEntry, footnotes []Footnote, dir string, options ArticleOptions) string {
+	markdown := injectHeadingAnchors(ac.convertToMarkdown(content, options.Markdown), headings, options.Markdown.MaxHeadingLevel) +
+		ac.renderFootnotesMarkdown(footnotes, options.Markdown)
+	if toc := nestTOCEntries(headings); options.IncludeTOC && len(toc) > 0 {
+		markdown = renderTOCMarkdown(toc) + "\n" + markdown
+	}
+	return wrapMarkdownDirection(markdown, dir)
 }
 
 // saveDebugHTML saves the cleaned HTML to a file for debugging
@@ -473,24 +590,63 @@ func (ac *ArticleCleaner) saveDebugHTML(doc *goquery.Document) {
 
 // CleanArticle processes a URL and returns a comprehensive cleaned article
 func (ac *ArticleCleaner) CleanArticle(pageURL string) (CleanedArticle, error) {
+	return ac.CleanArticleWithOptions(pageURL, DefaultArticleOptions())
+}
+
+// CleanArticleWithOptions processes a URL using the given options
+func (ac *ArticleCleaner) CleanArticleWithOptions(pageURL string, options ArticleOptions) (CleanedArticle, error) {
+	// Remove redirect wrappers and tracking parameters before fetching
+	pageURL = CleanURL(pageURL, options.TrackingParams)
+
 	// Fetch and parse the document
-	doc, baseURL, err := ac.fetchAndParseDocument(pageURL)
+	fetched, err := ac.fetchDocument(pageURL)
 	if err != nil {
 		return CleanedArticle{}, err
 	}
+	doc := fetched.doc
 
 	// Extract Open Graph data before removing elements
-	openGraphData := ac.extractOpenGraphData(doc, pageURL, baseURL)
+	openGraphData := ac.extractOpenGraphData(doc, pageURL, fetched.baseURL, options.TrackingParams)
+
+	// Relative URLs resolve against <base href> when the page declares one
+	baseURL := documentBaseURL(doc, fetched.baseURL)
+
+	// Recover images from noscript fallbacks before noscript is removed
+	ac.recoverNoscriptImages(doc)
+
+	// Normalize highlighted code before token classes such as "comment" match unwanted selectors
+	languageClasses := ac.normalizeCodeBlocks(doc)
+
+	// Recover TeX from MathJax and KaTeX before their source scripts are removed
+	ac.normalizeMath(doc)
+
+	// Move footnote definitions out of the page before footers are removed
+	footnotes := ac.extractFootnotes(doc)
+
+	// Keep embedded media as placeholders before embed wrappers and iframes are removed
+	ac.replaceEmbeds(doc)
+
+	// Keep table alignment and task list checkboxes, which readability strips
+	ac.preserveMarkdownHints(doc)
 
 	// Remove unwanted elements
 	ac.removeUnwantedElements(doc)
 
-	// Process images
-	ac.processImages(doc, baseURL)
+	// Process images, keeping the candidates that were not chosen
+	imageSelections := ac.processImages(doc, baseURL, options.ImageFormats)
+
+	// Resolve links and media so markdown never ends up with dangling relative URLs
+	ac.resolveDocumentURLs(doc, baseURL)
+	if options.RewriteLinks {
+		ac.rewriteTrackingLinks(doc, options.TrackingParams)
+	}
+	ac.resolveFootnoteURLs(footnotes, baseURL, options.TrackingParams, options.RewriteLinks)
 
 	// Convert to readability format
 	ac.logger.Info("Converting document to readability format")
-	article, err := readability.FromDocument(doc.Get(0), nil)
+	parser := readability.NewParser()
+	parser.ClassesToPreserve = append(parser.ClassesToPreserve, languageClasses...)
+	article, err := parser.ParseDocument(doc.Get(0), nil)
 	if err != nil {
 		ac.logger.Errorw("Failed to convert document to readability format", "error", err)
 		return CleanedArticle{}, err
@@ -499,35 +655,102 @@ func (ac *ArticleCleaner) CleanArticle(pageURL string) (CleanedArticle, error) {
 	// Clean the text content
 	cleanedTextContent := ac.cleanTextContent(article.TextContent)
 
-	// Convert to markdown
-	markdown := ac.convertToMarkdown(article.Content)
+	// Detect language and text direction
+	language := ac.detectLanguage(fetched, openGraphData, cleanedTextContent)
 
-	// Generate excerpt
-	excerpt := ac.generateExcerpt(cleanedTextContent, 200)
+	// Measure word count, reading time and readability
+	languageTag := ""
+	if language != nil {
+		languageTag = language.Language
+	}
+	stats := computeArticleStats(cleanedTextContent, languageTag, options.WordsPerMinute)
+
+	// Give headings slug ids and build the outline
+	headings, content := ac.buildTableOfContents(article.Content)
+	toc := nestTOCEntries(headings)
+	footnotes = filterFootnotes(footnotes, content)
+
+	// Convert to markdown with heading anchors, keeping right-to-left text direction
+	dir := ""
+	if language != nil {
+		dir = language.Dir
+	}
+	markdown := ac.renderArticleMarkdown(content, headings, footnotes, dir, options)
+
+	// Split the markdown for retrieval if requested
+	var chunks []Chunk
+	if options.Chunking != nil {
+		chunks = ChunkMarkdown(markdown, *options.Chunking)
+		ac.logger.Debugw("Chunked article markdown", "chunks", len(chunks))
+	}
+
+	// Generate excerpt and summary
+	excerpt := ac.generateExcerpt(cleanedTextContent, options.ExcerptLength)
+	if options.ExcerptMode == ExcerptModeSmart {
+		excerpt = ac.smartExcerpt(openGraphData, content, cleanedTextContent, options.ExcerptLength)
+	}
+	summary := summarizeText(cleanedTextContent, languageTag, options.SummarySentences)
+
+	// Rank keywords, merged with the page's tags and declared keywords
+	keywordLanguage := languageTag
+	if options.KeywordLanguage != "" {
+		keywordLanguage = options.KeywordLanguage
+	}
+	keywords := extractKeywords(cleanedTextContent, keywordLanguage, append(slices.Clone(openGraphData.Tags), openGraphData.Keywords...), options.KeywordStopwords, options.KeywordCount)
 
-	// Handle published time safely
-	var publishedAt string
-	if article.PublishedTime != nil {
-		publishedAt = article.PublishedTime.Format(time.RFC3339)
+	// Merge the readability byline with the metadata authors
+	authors := mergeAuthors(append(parseByline(article.Byline), openGraphData.Authors...))
+	author := strings.TrimSpace(article.Byline)
+	if names := authorNames(authors); names != "" {
+		author = names
 	}
 
+	// Resolve dates, falling back to readability's published time
+	dates := ac.resolveArticleDates(openGraphData.Dates, article.PublishedTime)
+
 	cleanedArticle := CleanedArticle{
-		Title:       strings.TrimSpace(article.Title),
-		Content:     cleanedTextContent,
-		Markdown:    markdown,
-		URL:         pageURL,
-		Author:      strings.TrimSpace(article.Byline),
-		Excerpt:     excerpt,
-		Length:      len(cleanedTextContent),
-		PublishedAt: publishedAt,
-		OpenGraph:   openGraphData,
+		Title:     strings.TrimSpace(article.Title),
+		Content:   cleanedTextContent,
+		Markdown:  markdown,
+		URL:       CleanURL(canonicalArticleURL(openGraphData, pageURL), options.TrackingParams),
+		Author:    author,
+		Authors:   authors,
+		Excerpt:   excerpt,
+		Summary:   summary,
+		Length:    len(cleanedTextContent),
+		Dates:     dates,
+		Images:    ac.collectArticleImages(content, imageSelections, openGraphData),
+		Embeds:    ac.collectArticleEmbeds(content),
+		Links:     ac.collectArticleLinks(content+footnotesHTML(footnotes), fetched.baseURL, options.TrackingParams),
+		Footnotes: footnotes,
+		Stats:     stats,
+		TOC:       toc,
+		Chunks:    chunks,
+		Keywords:  keywords,
+		Encoding:  fetched.encoding,
+		OpenGraph: openGraphData,
+		html:      content,
+		headings:  headings,
+	}
+
+	if language != nil {
+		cleanedArticle.Language = language.Language
+		cleanedArticle.LanguageConfidence = language.Confidence
+		cleanedArticle.Dir = language.Dir
+	}
+
+	if dates != nil && dates.Published != nil {
+		cleanedArticle.PublishedAt = dates.Published.Value
+	}
+	if dates != nil && dates.Modified != nil {
+		cleanedArticle.ModifiedAt = dates.Modified.Value
 	}
 
 	ac.logger.Infow("Successfully processed article",
 		"title_length", len(cleanedArticle.Title),
 		"content_length", cleanedArticle.Length,
 		"markdown_length", len(cleanedArticle.Markdown),
-		"url", pageURL,
+		"url", cleanedArticle.URL,
 	)
 
 	// Save debug HTML
@@ -540,12 +763,15 @@ func (ac *ArticleCleaner) CleanArticle(pageURL string) (CleanedArticle, error) {
 func (ac *ArticleCleaner) ExtractOpenGraphData(pageURL string) (*OpenGraphData, error) {
 	ac.logger.Infow("Starting to fetch Open Graph data", "url", pageURL)
 
+	// Remove redirect wrappers and tracking parameters before fetching
+	pageURL = CleanURL(pageURL, defaultTrackingParams)
+
 	doc, baseURL, err := ac.fetchAndParseDocument(pageURL)
 	if err != nil {
 		return &OpenGraphData{}, err
 	}
 
-	openGraphData := ac.extractOpenGraphData(doc, pageURL, baseURL)
+	openGraphData := ac.extractOpenGraphData(doc, pageURL, baseURL, defaultTrackingParams)
 	ac.logger.Infow("Successfully extracted Open Graph data", "url", pageURL, "title", openGraphData.Title)
 
 	return openGraphData, nil
@@ -561,13 +787,18 @@ func GetReadableArticle(url string) string {
 
 // GetCleanedArticle returns a comprehensive cleaned article with markdown
 func GetCleanedArticle(url string) CleanedArticle {
+	return GetCleanedArticleWithOptions(url, DefaultArticleOptions())
+}
+
+// GetCleanedArticleWithOptions returns a cleaned article processed with the given options
+func GetCleanedArticleWithOptions(url string, options ArticleOptions) CleanedArticle {
 	cleaner, err := NewArticleCleaner()
 	if err != nil {
 		return CleanedArticle{}
 	}
 	defer cleaner.Close()
 
-	article, err := cleaner.CleanArticle(url)
+	article, err := cleaner.CleanArticleWithOptions(url, options)
 	if err != nil {
 		cleaner.logger.Errorw("Failed to get readable article", "url", url, "error", err)
 		return CleanedArticle{}
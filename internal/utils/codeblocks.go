@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// codeGutterSelectors match line-number gutters added by syntax highlighters
+var codeGutterSelectors = []string{
+	".line-numbers-rows", ".linenos", ".lineno", ".linenodiv", ".lnt", ".ln",
+	".hljs-ln-numbers", ".blob-num", ".gutter", ".CodeMirror-gutter-wrapper",
+	".CodeMirror-linenumber", ".react-syntax-highlighter-line-number", ".line-number",
+	"td.rouge-gutter", "td.lntd:first-child",
+}
+
+// codeTableSelectors match highlighters that lay code out as a table of gutter and code cells
+var codeTableSelectors = []string{
+	"table.highlighttable", "table.rouge-table", "table.lntable", "table.hljs-ln",
+	"table.js-file-line-container", "table.highlight",
+}
+
+// codeEditorSelectors match code editor widgets that render each line as its own element
+var codeEditorSelectors = []string{".CodeMirror-code", ".cm-content", ".view-lines"}
+
+// codeLineSelectors match per-line wrappers whose text has no newlines of its own
+var codeLineSelectors = []string{".CodeMirror-line", ".cm-line", ".code-line", ".view-line"}
+
+// codeLanguageClassPatterns extract the language from highlighter class names
+var codeLanguageClassPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:language|lang)-([\w+#.-]+)$`),
+	regexp.MustCompile(`^highlight-(?:source|text)-([\w+#.-]+)$`),
+	regexp.MustCompile(`^highlight-([\w+#.-]+)$`),
+	regexp.MustCompile(`^brush:([\w+#.-]+);?$`),
+}
+
+// codeLanguageMarkerClasses are classes next to which a bare class name is the language,
+// e.g. "hljs python" or "sourceCode haskell"
+var codeLanguageMarkerClasses = []string{"hljs", "sourceCode"}
+
+// codeLanguageAliases maps highlighter language names to common fenced code info strings
+var codeLanguageAliases = map[string]string{
+	"golang": "go", "js": "javascript", "node": "javascript", "ts": "typescript",
+	"py": "python", "py3": "python", "python3": "python", "rb": "ruby", "rs": "rust",
+	"kt": "kotlin", "cs": "csharp", "c#": "csharp", "c++": "cpp", "cxx": "cpp", "h": "c",
+	"objc": "objectivec", "objective-c": "objectivec", "sh": "bash", "shell": "bash",
+	"zsh": "bash", "shellscript": "bash", "shell-session": "console", "shellsession": "console",
+	"ps1": "powershell", "yml": "yaml", "md": "markdown", "markup": "html", "xhtml": "html",
+	"html-basic": "html", "docker": "dockerfile", "tf": "hcl", "terraform": "hcl", "proto": "protobuf",
+	"text": "", "txt": "", "plain": "", "plaintext": "", "none": "", "nohighlight": "", "output": "",
+}
+
+// codeLanguagePattern matches plausible language names
+var codeLanguagePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9+#._-]{0,30}$`)
+
+// normalizeCodeBlocks rewrites syntax-highlighter markup (Prism, highlight.js, Pygments, Rouge,
+// Chroma, GitHub, CodeMirror, Monaco) into plain <pre><code class="language-x"> blocks, dropping
+// line-number gutters and token spans. It returns the language classes used, which readability
+// must preserve.
+func (ac *ArticleCleaner) normalizeCodeBlocks(doc *goquery.Document) []string {
+	// Code laid out as tables becomes a single <pre> per table
+	doc.Find(strings.Join(codeTableSelectors, ", ")).Each(func(i int, table *goquery.Selection) {
+		table.Find(strings.Join(codeGutterSelectors, ", ")).Remove()
+
+		var lines []string
+		rows := table.Find("tr")
+		rows.Each(func(j int, row *goquery.Selection) {
+			lines = append(lines, codeText(row))
+		})
+		text := strings.Join(lines, "\n")
+		if rows.Length() == 1 {
+			text = lines[0]
+		}
+
+		pre := table.Find("pre").First()
+		language := codeBlockLanguage(table)
+		if language == "" && pre.Length() > 0 {
+			language = codeBlockLanguage(pre)
+		}
+		table.ReplaceWithHtml(codeBlockHTML(text, language))
+	})
+
+	// Editor widgets become a single <pre> with one line per line element
+	doc.Find(strings.Join(codeEditorSelectors, ", ")).Each(func(i int, editor *goquery.Selection) {
+		editor.Find(strings.Join(codeGutterSelectors, ", ")).Remove()
+		editor.ReplaceWithHtml(codeBlockHTML(codeText(editor), codeBlockLanguage(editor)))
+	})
+
+	languages := make(map[string]bool)
+	var classes []string
+	count := 0
+	doc.Find("pre").Each(func(i int, pre *goquery.Selection) {
+		if pre.ParentsFiltered("pre").Length() > 0 {
+			return
+		}
+		pre.Find(strings.Join(codeGutterSelectors, ", ")).Remove()
+
+		text := codeText(pre)
+		language := codeBlockLanguage(pre)
+		pre.ReplaceWithHtml(codeBlockHTML(text, language))
+		count++
+
+		if language != "" && !languages[language] {
+			languages[language] = true
+			classes = append(classes, "language-"+language)
+		}
+	})
+
+	if count > 0 {
+		ac.logger.Debugw("Normalized code blocks", "count", count, "languages", classes)
+	}
+	return classes
+}
+
+// codeText returns the text of highlighted code, turning <br> and per-line wrappers into
+// newlines and dropping token spans
+func codeText(s *goquery.Selection) string {
+	s.Find("br").ReplaceWithHtml("\n")
+
+	lines := s.Find(strings.Join(codeLineSelectors, ", "))
+	if lines.Length() > 0 {
+		var text []string
+		lines.Each(func(i int, line *goquery.Selection) {
+			text = append(text, strings.TrimSuffix(line.Text(), "\n"))
+		})
+		return strings.Join(text, "\n")
+	}
+
+	// Row-per-line tables keep their cells on one line
+	if goquery.NodeName(s) == "tr" {
+		var cells []string
+		s.Find("td, th").Each(func(i int, cell *goquery.Selection) {
+			cells = append(cells, strings.TrimSuffix(cell.Text(), "\n"))
+		})
+		return strings.Join(cells, "")
+	}
+
+	return strings.Trim(s.Text(), "\n")
+}
+
+// codeBlockLanguage finds the language of a code block from the classes and data-lang or
+// data-language attributes of the block, its <code> child and its nearest wrappers
+func codeBlockLanguage(block *goquery.Selection) string {
+	candidates := []*goquery.Selection{block.Find("code").First(), block}
+	for parent, depth := block.Parent(), 0; parent.Length() > 0 && depth < 3; parent, depth = parent.Parent(), depth+1 {
+		candidates = append(candidates, parent)
+	}
+
+	for _, s := range candidates {
+		if s.Length() == 0 {
+			continue
+		}
+		for _, attr := range []string{"data-lang", "data-language"} {
+			if value, ok := s.Attr(attr); ok {
+				if language, ok := normalizeCodeLanguage(value); ok {
+					return language
+				}
+			}
+		}
+		if language, ok := classCodeLanguage(s.AttrOr("class", "")); ok {
+			return language
+		}
+	}
+	return ""
+}
+
+// classCodeLanguage extracts a language from highlighter class names
+func classCodeLanguage(class string) (string, bool) {
+	classes := strings.Fields(strings.ReplaceAll(class, "brush: ", "brush:"))
+	for _, name := range classes {
+		for _, pattern := range codeLanguageClassPatterns {
+			if match := pattern.FindStringSubmatch(name); match != nil {
+				if language, ok := normalizeCodeLanguage(match[1]); ok {
+					return language, true
+				}
+			}
+		}
+	}
+
+	if !slices.ContainsFunc(classes, func(name string) bool { return slices.Contains(codeLanguageMarkerClasses, name) }) {
+		return "", false
+	}
+	for _, name := range classes {
+		if slices.Contains(codeLanguageMarkerClasses, name) {
+			continue
+		}
+		if language, ok := normalizeCodeLanguage(name); ok {
+			return language, true
+		}
+	}
+	return "", false
+}
+
+// normalizeCodeLanguage lowercases a language name and maps aliases; ok is false for values
+// that are not language names
+func normalizeCodeLanguage(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := codeLanguageAliases[name]; ok {
+		return alias, true
+	}
+	if !codeLanguagePattern.MatchString(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// codeBlockHTML renders code as a plain <pre><code> block with an optional language class
+func codeBlockHTML(text, language string) string {
+	class := ""
+	if language != "" {
+		class = ` class="language-` + html.EscapeString(language) + `"`
+	}
+	return "<pre><code" + class + ">" + html.EscapeString(text) + "</code></pre>"
+}
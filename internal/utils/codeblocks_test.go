@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestNormalizeCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "prism with line numbers",
+			html: `<pre class="language-go line-numbers"><code class="language-go"><span class="token keyword">func</span> <span class="token function">main</span><span class="token punctuation">()</span> <span class="token punctuation">{</span>
+	<span class="token comment">// say hi</span>
+<span class="token punctuation">}</span><span aria-hidden="true" class="line-numbers-rows"><span></span><span></span><span></span></span></code></pre>`,
+			want: `<pre><code class="language-go">func main() {
+	// say hi
+}</code></pre>`,
+		},
+		{
+			name: "highlight.js",
+			html: `<pre><code class="hljs python"><span class="hljs-keyword">def</span> <span class="hljs-title">f</span>():
+    <span class="hljs-keyword">return</span> <span class="hljs-number">1</span></code></pre>`,
+			want: `<pre><code class="language-python">def f():
+    return 1</code></pre>`,
+		},
+		{
+			name: "github wrapper",
+			html: `<div class="highlight highlight-source-shell notranslate"><pre><span class="pl-c1">echo</span> <span class="pl-s">"hi"</span></pre></div>`,
+			want: `<div class="highlight highlight-source-shell notranslate"><pre><code class="language-bash">echo &#34;hi&#34;</code></pre></div>`,
+		},
+		{
+			name: "pygments table",
+			html: `<table class="highlighttable"><tbody><tr><td class="linenos"><div class="linenodiv"><pre>1
+2</pre></div></td><td class="code"><div class="highlight"><pre><span class="n">x</span> <span class="o">=</span> <span class="mi">1</span>
+<span class="nb">print</span><span class="p">(</span><span class="n">x</span><span class="p">)</span></pre></div></td></tr></tbody></table>`,
+			want: `<pre><code>x = 1
+print(x)</code></pre>`,
+		},
+		{
+			name: "hljs line table",
+			html: `<pre><code class="language-js"><table class="hljs-ln"><tbody><tr><td class="hljs-ln-numbers"><div class="hljs-ln-n" data-line-number="1"></div></td><td class="hljs-ln-code">let a = 1;</td></tr><tr><td class="hljs-ln-numbers"><div class="hljs-ln-n" data-line-number="2"></div></td><td class="hljs-ln-code">a++;</td></tr></tbody></table></code></pre>`,
+			want: `<pre><code class="language-javascript">let a = 1;
+a++;</code></pre>`,
+		},
+		{
+			name: "data-lang and br",
+			html: `<pre data-lang="YML">a: 1<br>b: 2</pre>`,
+			want: `<pre><code class="language-yaml">a: 1
+b: 2</code></pre>`,
+		},
+		{
+			name: "syntaxhighlighter brush",
+			html: `<pre class="brush: ruby; gutter: false">puts 1</pre>`,
+			want: `<pre><code class="language-ruby">puts 1</code></pre>`,
+		},
+		{
+			name: "plain text",
+			html: `<pre><code class="language-plaintext">&lt;none&gt;</code></pre>`,
+			want: `<pre><code>&lt;none&gt;</code></pre>`,
+		},
+		{
+			name: "codemirror",
+			html: `<div class="CodeMirror"><div class="CodeMirror-code"><div><div class="CodeMirror-gutter-wrapper"><div class="CodeMirror-linenumber">1</div></div><pre class="CodeMirror-line"><span>SELECT 1;</span></pre></div><div><div class="CodeMirror-gutter-wrapper"><div class="CodeMirror-linenumber">2</div></div><pre class="CodeMirror-line"><span>SELECT 2;</span></pre></div></div></div>`,
+			want: `<div class="CodeMirror"><pre><code>SELECT 1;
+SELECT 2;</code></pre></div>`,
+		},
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<body>" + tt.html + "</body>"))
+			if err != nil {
+				t.Fatalf("Failed to parse HTML: %v", err)
+			}
+			ac.normalizeCodeBlocks(doc)
+			got, _ := doc.Find("body").Html()
+			if got != tt.want {
+				t.Errorf("normalizeCodeBlocks() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeBlockLanguages(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<body>
+		<pre><code class="language-golang">a</code></pre>
+		<pre><code class="lang-ts">b</code></pre>
+		<pre><code class="language-go">c</code></pre>
+		<div class="sourceCode"><pre class="sourceCode haskell">d</pre></div>
+		<pre><code>e</code></pre>
+	</body>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	want := []string{"language-go", "language-typescript", "language-haskell"}
+	if got := ac.normalizeCodeBlocks(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeCodeBlocks() = %q, want %q", got, want)
+	}
+}
+
+func TestCleanArticleCodeBlocks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>Writing Go</title></head>
+<body>
+	<article>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<div class="highlight highlight-source-go"><pre><span class="pl-k">package</span> main
+
+<span class="token comment">// entry point</span>
+<span class="pl-k">func</span> <span class="pl-en">main</span>() {}</pre></div>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	want := "```go\npackage main\n\n// entry point\nfunc main() {}\n```"
+	if !strings.Contains(article.Markdown, want) {
+		t.Errorf("Expected markdown to contain %q, got:\n%s", want, article.Markdown)
+	}
+}
@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/araddon/dateparse"
+)
+
+// DateInfo represents a normalized date together with where it came from
+type DateInfo struct {
+	Value      string  `json:"value"`
+	Source     string  `json:"source"`
+	Confidence float64 `json:"confidence"`
+	Raw        string  `json:"raw,omitempty"`
+}
+
+// ArticleDates holds the normalized published and modified dates of a page
+type ArticleDates struct {
+	Published *DateInfo `json:"published,omitempty"`
+	Modified  *DateInfo `json:"modified,omitempty"`
+}
+
+// dateCandidate is a raw date string found on the page
+type dateCandidate struct {
+	raw        string
+	source     string
+	confidence float64
+}
+
+// Base confidence for each date source, before parsing penalties
+const (
+	dateConfidenceJSONLD      = 0.95
+	dateConfidenceOpenGraph   = 0.9
+	dateConfidenceItemprop    = 0.85
+	dateConfidenceMeta        = 0.75
+	dateConfidenceTimeElement = 0.7
+	dateConfidenceReadability = 0.6
+	dateConfidenceURL         = 0.5
+)
+
+var (
+	// publishedMetaNames are meta name/property values that commonly carry the publish date
+	publishedMetaNames = []string{
+		"date", "pubdate", "publishdate", "publish-date", "publish_date", "published_time",
+		"article.published", "dc.date", "dc.date.issued", "dcterms.created", "dcterms.date",
+		"sailthru.date", "parsely-pub-date", "cxenseparse:recs:publishtime", "og:published_time",
+	}
+
+	// modifiedMetaNames are meta name/property values that commonly carry the modified date
+	modifiedMetaNames = []string{
+		"last-modified", "lastmod", "dc.date.modified", "dcterms.modified", "article.updated",
+		"og:updated_time", "updated_time",
+	}
+
+	// urlDatePatterns match dates embedded in URL paths, most specific first
+	urlDatePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`/((?:19|20)\d{2})/(\d{1,2})/(\d{1,2})(?:/|$)`),
+		regexp.MustCompile(`/((?:19|20)\d{2})-(\d{2})-(\d{2})(?:[/-]|$)`),
+		regexp.MustCompile(`/((?:19|20)\d{2})(\d{2})(\d{2})(?:/|$)`),
+		regexp.MustCompile(`/((?:19|20)\d{2})/(\d{1,2})(?:/|$)`),
+	}
+
+	ordinalSuffixPattern = regexp.MustCompile(`(?i)\b(\d{1,2})(st|nd|rd|th)\b`)
+	explicitZonePattern  = regexp.MustCompile(`(\dZ|[+-]\d{2}:?\d{2}|(?i:\bgmt|\butc))$`)
+	timeOfDayPattern     = regexp.MustCompile(`(?:^|[^+\-\d])\d{1,2}:\d{2}`)
+	epochPattern         = regexp.MustCompile(`^\d{10}(\d{3})?$`)
+	zoneAbbrevPattern    = regexp.MustCompile(`\s*\(?\b([A-Z]{3,4})\)?$`)
+
+	// zoneAbbreviations maps common timezone abbreviations to their UTC offsets in hours
+	zoneAbbreviations = map[string]float64{
+		"EST": -5, "EDT": -4, "CST": -6, "CDT": -5, "MST": -7, "MDT": -6, "PST": -8, "PDT": -7,
+		"AKST": -9, "AKDT": -8, "HST": -10, "BST": 1, "IST": 5.5, "CET": 1, "CEST": 2,
+		"EET": 2, "EEST": 3, "WET": 0, "WEST": 1, "MSK": 3, "JST": 9, "KST": 9,
+		"SGT": 8, "HKT": 8, "AEST": 10, "AEDT": 11, "ACST": 9.5, "AWST": 8, "NZST": 12, "NZDT": 13,
+	}
+)
+
+// extractArticleDates collects date candidates from every source and keeps the most confident ones
+func (ac *ArticleCleaner) extractArticleDates(doc *goquery.Document, jsonLD []map[string]interface{}, og *OpenGraphData, pageURL *url.URL) *ArticleDates {
+	var published, modified []dateCandidate
+
+	// JSON-LD
+	for _, obj := range jsonLD {
+		if raw := jsonLDString(obj["datePublished"]); raw != "" {
+			published = append(published, dateCandidate{raw, "json-ld", dateConfidenceJSONLD})
+		}
+		if raw := jsonLDString(obj["dateModified"]); raw != "" {
+			modified = append(modified, dateCandidate{raw, "json-ld", dateConfidenceJSONLD})
+		}
+	}
+
+	// Open Graph
+	if og.PublishedAt != "" {
+		published = append(published, dateCandidate{og.PublishedAt, "open_graph", dateConfidenceOpenGraph})
+	}
+	if og.ModifiedAt != "" {
+		modified = append(modified, dateCandidate{og.ModifiedAt, "open_graph", dateConfidenceOpenGraph})
+	}
+
+	// Microdata
+	doc.Find("[itemprop='datePublished'], [itemprop='dateModified']").Each(func(i int, s *goquery.Selection) {
+		raw := s.AttrOr("content", s.AttrOr("datetime", strings.TrimSpace(s.Text())))
+		if raw == "" {
+			return
+		}
+		if s.AttrOr("itemprop", "") == "datePublished" {
+			published = append(published, dateCandidate{raw, "itemprop", dateConfidenceItemprop})
+		} else {
+			modified = append(modified, dateCandidate{raw, "itemprop", dateConfidenceItemprop})
+		}
+	})
+
+	// Other meta tags
+	doc.Find("meta[content]").Each(func(i int, s *goquery.Selection) {
+		key := strings.ToLower(s.AttrOr("name", s.AttrOr("property", s.AttrOr("http-equiv", ""))))
+		raw := strings.TrimSpace(s.AttrOr("content", ""))
+		if key == "" || raw == "" {
+			return
+		}
+		for _, name := range publishedMetaNames {
+			if key == name {
+				published = append(published, dateCandidate{raw, "meta:" + key, dateConfidenceMeta})
+				return
+			}
+		}
+		for _, name := range modifiedMetaNames {
+			if key == name {
+				modified = append(modified, dateCandidate{raw, "meta:" + key, dateConfidenceMeta})
+				return
+			}
+		}
+	})
+
+	// <time datetime>, preferring ones explicitly marked as the publish date
+	doc.Find("time[datetime]").Each(func(i int, s *goquery.Selection) {
+		raw := strings.TrimSpace(s.AttrOr("datetime", ""))
+		if raw == "" {
+			return
+		}
+		confidence := dateConfidenceTimeElement
+		if _, ok := s.Attr("pubdate"); ok || i == 0 {
+			confidence += 0.05
+		}
+		published = append(published, dateCandidate{raw, "time", confidence})
+	})
+
+	// URL path
+	if raw := dateFromURLPath(pageURL); raw != "" {
+		published = append(published, dateCandidate{raw, "url", dateConfidenceURL})
+	}
+
+	dates := &ArticleDates{
+		Published: bestDate(published),
+		Modified:  bestDate(modified),
+	}
+	if dates.Published == nil && dates.Modified == nil {
+		return nil
+	}
+
+	ac.logger.Debugw("Normalized article dates",
+		"published", dates.Published,
+		"modified", dates.Modified,
+		"published_candidates", len(published),
+		"modified_candidates", len(modified),
+	)
+
+	return dates
+}
+
+// bestDate parses every candidate and returns the one with the highest confidence
+func bestDate(candidates []dateCandidate) *DateInfo {
+	var best *DateInfo
+	for _, candidate := range candidates {
+		info := normalizeDate(candidate.raw, candidate.source, candidate.confidence)
+		if info == nil {
+			continue
+		}
+		if best == nil || info.Confidence > best.Confidence {
+			best = info
+		}
+	}
+	return best
+}
+
+// normalizeDate parses a raw date string into RFC3339, lowering confidence when the
+// value has no time of day or no explicit timezone
+func normalizeDate(raw, source string, confidence float64) *DateInfo {
+	parsed, hasZone, hasTime, ok := parseDate(raw)
+	if !ok {
+		return nil
+	}
+
+	if !hasTime {
+		confidence -= 0.1
+	}
+	if !hasZone {
+		confidence -= 0.05
+	}
+
+	return &DateInfo{
+		Value:      parsed.Format(time.RFC3339),
+		Source:     source,
+		Confidence: float64(int(confidence*100+0.5)) / 100,
+		Raw:        raw,
+	}
+}
+
+// parseDate parses dates in ISO 8601, RFC 1123, unix epoch and common human-readable forms
+func parseDate(raw string) (parsed time.Time, hasZone bool, hasTime bool, ok bool) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return time.Time{}, false, false, false
+	}
+
+	// Unix epochs in seconds or milliseconds
+	if epochPattern.MatchString(value) {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, false, false, false
+		}
+		if len(value) == 13 {
+			parsed = time.UnixMilli(n).UTC()
+		} else {
+			parsed = time.Unix(n, 0).UTC()
+		}
+		return parsed, true, true, isPlausibleDate(parsed)
+	}
+
+	// "Jan 5th, 2024" -> "Jan 5, 2024"
+	value = ordinalSuffixPattern.ReplaceAllString(value, "$1")
+
+	loc := time.UTC
+	hasZone = explicitZonePattern.MatchString(value)
+	if !hasZone {
+		if match := zoneAbbrevPattern.FindStringSubmatch(value); match != nil {
+			if offset, known := zoneAbbreviations[match[1]]; known {
+				loc = time.FixedZone(match[1], int(offset*3600))
+				value = strings.TrimSpace(value[:len(value)-len(match[0])])
+				hasZone = true
+			}
+		}
+	}
+
+	parsed, err := dateparse.ParseIn(value, loc)
+	if err != nil {
+		return time.Time{}, false, false, false
+	}
+
+	hasTime = timeOfDayPattern.MatchString(value)
+	return parsed, hasZone, hasTime, isPlausibleDate(parsed)
+}
+
+// isPlausibleDate rejects zero values and dates too far in the past or future to be publish dates
+func isPlausibleDate(t time.Time) bool {
+	return t.Year() >= 1990 && t.Before(time.Now().Add(48*time.Hour))
+}
+
+// dateFromURLPath extracts a YYYY-MM-DD date from common URL path patterns like /2024/01/05/
+func dateFromURLPath(pageURL *url.URL) string {
+	if pageURL == nil {
+		return ""
+	}
+
+	for _, pattern := range urlDatePatterns {
+		match := pattern.FindStringSubmatch(pageURL.Path)
+		if match == nil {
+			continue
+		}
+
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		day := 1
+		if len(match) > 3 {
+			day, _ = strconv.Atoi(match[3])
+		}
+		if month < 1 || month > 12 || day < 1 || day > 31 {
+			continue
+		}
+
+		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	}
+
+	return ""
+}
+
+// resolveArticleDates fills in the published date from readability when no page metadata provided one
+func (ac *ArticleCleaner) resolveArticleDates(dates *ArticleDates, readabilityPublished *time.Time) *ArticleDates {
+	if dates != nil && dates.Published != nil {
+		return dates
+	}
+	if readabilityPublished == nil {
+		return dates
+	}
+
+	published := normalizeDate(readabilityPublished.Format(time.RFC3339), "readability", dateConfidenceReadability)
+	if published == nil {
+		return dates
+	}
+
+	resolved := &ArticleDates{Published: published}
+	if dates != nil {
+		resolved.Modified = dates.Modified
+	}
+	return resolved
+}
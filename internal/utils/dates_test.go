@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNormalizeDate(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"2025-01-15T10:00:00Z", "2025-01-15T10:00:00Z"},
+		{"2024-01-05T10:00:00+0530", "2024-01-05T10:00:00+05:30"},
+		{"Jan 5th, 2024", "2024-01-05T00:00:00Z"},
+		{"1704448800", "2024-01-05T10:00:00Z"},
+		{"1704448800000", "2024-01-05T10:00:00Z"},
+		{"Fri, 05 Jan 2024 10:00:00 GMT", "2024-01-05T10:00:00Z"},
+		{"January 5, 2024 10:00 AM EST", "2024-01-05T10:00:00-05:00"},
+		{"5 January 2024", "2024-01-05T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		info := normalizeDate(tt.raw, "test", 1)
+		if info == nil {
+			t.Errorf("%q: failed to normalize", tt.raw)
+			continue
+		}
+		if info.Value != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.raw, info.Value, tt.want)
+		}
+	}
+
+	for _, raw := range []string{"", "yesterday", "not a date", "0"} {
+		if info := normalizeDate(raw, "test", 1); info != nil {
+			t.Errorf("%q: expected no date, got %q", raw, info.Value)
+		}
+	}
+}
+
+func TestNormalizeDateConfidence(t *testing.T) {
+	full := normalizeDate("2024-01-05T10:00:00Z", "test", 0.9)
+	dateOnly := normalizeDate("2024-01-05", "test", 0.9)
+	if full.Confidence != 0.9 {
+		t.Errorf("Expected full timestamp to keep confidence 0.9, got %v", full.Confidence)
+	}
+	if dateOnly.Confidence >= full.Confidence {
+		t.Errorf("Expected date-only value to have lower confidence, got %v", dateOnly.Confidence)
+	}
+}
+
+func TestParseDateTimeOfDay(t *testing.T) {
+	tests := []struct {
+		raw     string
+		hasTime bool
+	}{
+		{"2024-01-05T10:00:00+05:30", true},
+		{"2024-01-05 9:30", true},
+		{"Jan 5, 2024 10:30", true},
+		{"2024-01-05", false},
+		{"2024-01-05+05:30", false},
+		{"2024-01-05-08:00", false},
+	}
+
+	for _, tt := range tests {
+		_, _, hasTime, ok := parseDate(tt.raw)
+		if !ok {
+			t.Errorf("%q: failed to parse", tt.raw)
+			continue
+		}
+		if hasTime != tt.hasTime {
+			t.Errorf("%q: got hasTime %v, want %v", tt.raw, hasTime, tt.hasTime)
+		}
+	}
+}
+
+func TestDateFromURLPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/2024/01/05/some-post/", "2024-01-05"},
+		{"/news/2024-01-05-some-post", "2024-01-05"},
+		{"/blog/2023/7/some-post", "2023-07-01"},
+		{"/blog/20240105/some-post", "2024-01-05"},
+		{"/blog/some-post", ""},
+		{"/2024/13/40/bad", ""},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse("https://example.com" + tt.path)
+		if got := dateFromURLPath(u); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExtractOpenGraphDataNormalizesDates(t *testing.T) {
+	html := `<html>
+<head>
+	<title>Dates</title>
+	<meta property="article:published_time" content="Jan 5th, 2024" />
+	<meta name="last-modified" content="1704880800" />
+	<script type="application/ld+json">
+	{"@context": "https://schema.org", "@graph": [
+		{"@type": "NewsArticle", "datePublished": "2024-01-05T10:00:00+0530"}
+	]}
+	</script>
+</head>
+<body><time datetime="2023-12-31">Dec 31</time><p>Content</p></body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(html))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	og, err := ac.ExtractOpenGraphData(ts.URL + "/2022/02/02/post")
+	if err != nil {
+		t.Fatalf("ExtractOpenGraphData failed: %v", err)
+	}
+	if og.Dates == nil || og.Dates.Published == nil || og.Dates.Modified == nil {
+		t.Fatalf("Expected published and modified dates, got %+v", og.Dates)
+	}
+
+	if og.PublishedAt != "2024-01-05T10:00:00+05:30" {
+		t.Errorf("Expected JSON-LD published date, got %q", og.PublishedAt)
+	}
+	if og.Dates.Published.Source != "json-ld" {
+		t.Errorf("Expected json-ld source, got %q", og.Dates.Published.Source)
+	}
+	if og.ModifiedAt != "2024-01-10T10:00:00Z" {
+		t.Errorf("Expected epoch modified date, got %q", og.ModifiedAt)
+	}
+	if og.Dates.Modified.Source != "meta:last-modified" {
+		t.Errorf("Expected meta:last-modified source, got %q", og.Dates.Modified.Source)
+	}
+}
@@ -0,0 +1,286 @@
+package utils
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Embed providers
+const (
+	EmbedProviderYouTube   = "youtube"
+	EmbedProviderVimeo     = "vimeo"
+	EmbedProviderTwitter   = "twitter"
+	EmbedProviderInstagram = "instagram"
+	EmbedProviderCodePen   = "codepen"
+	EmbedProviderGist      = "gist"
+	EmbedProviderSpotify   = "spotify"
+)
+
+// Embed is embedded media kept in the article as a placeholder
+type Embed struct {
+	Provider  string `json:"provider"`
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Title     string `json:"title,omitempty"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+}
+
+// embedSelectors match embedded media markup, whether rendered as an iframe or still waiting for
+// the provider's script
+var embedSelectors = []string{
+	"iframe[src]", "iframe[data-src]", "lite-youtube[videoid]", "lite-vimeo[videoid]",
+	"blockquote.twitter-tweet", "blockquote.twitter-video", "blockquote.instagram-media",
+	".codepen[data-slug-hash]", `script[src*="gist.github.com/"]`,
+}
+
+// embedWrapperSelectors match wrappers that removeUnwantedElements would remove together with
+// the placeholder, so the placeholder replaces them instead
+var embedWrapperSelectors = []string{".embed", ".video-player", ".twitter", ".instagram"}
+
+// embedLabels name embeds that have no title
+var embedLabels = map[string]string{
+	EmbedProviderYouTube: "YouTube video", EmbedProviderVimeo: "Vimeo video",
+	EmbedProviderTwitter: "Tweet", EmbedProviderInstagram: "Instagram post",
+	EmbedProviderCodePen: "CodePen", EmbedProviderGist: "GitHub Gist", EmbedProviderSpotify: "Spotify",
+}
+
+var (
+	youTubeIDPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	numericIDPattern   = regexp.MustCompile(`^[0-9]+$`)
+	embedIDPattern     = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	spotifyKindPattern = regexp.MustCompile(`^(?:track|album|playlist|episode|show|artist)$`)
+)
+
+// replaceEmbeds replaces embedded media with placeholders carrying the provider, id and
+// canonical URL. It runs before removeUnwantedElements, which removes embed wrappers and video
+// iframes, and before provider scripts such as Gist's are removed.
+func (ac *ArticleCleaner) replaceEmbeds(doc *goquery.Document) {
+	count := 0
+	doc.Find(strings.Join(embedSelectors, ", ")).Each(func(i int, element *goquery.Selection) {
+		embed, ok := parseEmbedElement(element)
+		if !ok {
+			return
+		}
+
+		target := element
+		if wrapper := element.Closest(strings.Join(embedWrapperSelectors, ", ")); wrapper.Length() > 0 &&
+			wrapper.Find(strings.Join(embedSelectors, ", ")).Length() <= 1 {
+			target = wrapper
+		}
+		target.ReplaceWithHtml(embedPlaceholderHTML(embed))
+		count++
+	})
+
+	if count > 0 {
+		ac.logger.Debugw("Replaced embeds with placeholders", "count", count)
+	}
+}
+
+// parseEmbedElement identifies the embedded media of an element
+func parseEmbedElement(element *goquery.Selection) (Embed, bool) {
+	var embed Embed
+	var ok bool
+	title := ""
+
+	switch {
+	case element.Is("iframe"):
+		src := element.AttrOr("src", "")
+		if src == "" || strings.HasPrefix(src, "about:") {
+			src = element.AttrOr("data-src", "")
+		}
+		embed, ok = parseEmbedURL(src)
+		title = element.AttrOr("title", "")
+	case element.Is("lite-youtube"):
+		embed, ok = parseEmbedURL("https://www.youtube.com/watch?v=" + url.QueryEscape(element.AttrOr("videoid", "")))
+		title = element.AttrOr("playlabel", "")
+	case element.Is("lite-vimeo"):
+		embed, ok = parseEmbedURL("https://vimeo.com/" + url.PathEscape(element.AttrOr("videoid", "")))
+	case element.Is("blockquote.twitter-tweet, blockquote.twitter-video"):
+		embed, ok = parseEmbedURL(element.Find(`a[href*="/status/"]`).Last().AttrOr("href", ""))
+		title = element.Find("p").First().Text()
+	case element.Is("blockquote.instagram-media"):
+		permalink := element.AttrOr("data-instgrm-permalink", "")
+		if permalink == "" {
+			permalink = element.Find(`a[href*="instagram.com/"]`).First().AttrOr("href", "")
+		}
+		embed, ok = parseEmbedURL(permalink)
+	case element.Is(".codepen"):
+		embed, ok = parseEmbedURL("https://codepen.io/" + url.PathEscape(element.AttrOr("data-user", "anon")) + "/pen/" + url.PathEscape(element.AttrOr("data-slug-hash", "")))
+		title = element.Find("a").First().Text()
+	case element.Is("script"):
+		embed, ok = parseEmbedURL(element.AttrOr("src", ""))
+	}
+
+	if !ok {
+		return Embed{}, false
+	}
+	embed.Title = strings.Join(strings.Fields(title), " ")
+	return embed, true
+}
+
+// parseEmbedURL identifies the provider and id of an embed or media URL and returns the embed
+// with its canonical URL
+func parseEmbedURL(rawURL string) (Embed, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if strings.HasPrefix(rawURL, "//") {
+		rawURL = "https:" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return Embed{}, false
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www."), "m.")
+	segments := strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' })
+	segment := func(i int) string {
+		if i < len(segments) {
+			return segments[i]
+		}
+		return ""
+	}
+
+	switch host {
+	case "youtube.com", "youtube-nocookie.com", "youtu.be":
+		id := parsed.Query().Get("v")
+		switch {
+		case host == "youtu.be":
+			id = segment(0)
+		case segment(0) == "embed" || segment(0) == "shorts" || segment(0) == "v" || segment(0) == "live":
+			id = segment(1)
+		}
+		if !youTubeIDPattern.MatchString(id) {
+			return Embed{}, false
+		}
+		return Embed{
+			Provider:  EmbedProviderYouTube,
+			ID:        id,
+			URL:       "https://www.youtube.com/watch?v=" + id,
+			Thumbnail: "https://i.ytimg.com/vi/" + id + "/hqdefault.jpg",
+		}, true
+	case "vimeo.com", "player.vimeo.com":
+		id := segment(0)
+		if host == "player.vimeo.com" && id == "video" {
+			id = segment(1)
+		}
+		if !numericIDPattern.MatchString(id) {
+			return Embed{}, false
+		}
+		return Embed{Provider: EmbedProviderVimeo, ID: id, URL: "https://vimeo.com/" + id}, true
+	case "twitter.com", "x.com", "platform.twitter.com", "mobile.twitter.com":
+		id, user := parsed.Query().Get("id"), ""
+		if segment(1) == "status" {
+			id, user = segment(2), segment(0)
+		}
+		if !numericIDPattern.MatchString(id) {
+			return Embed{}, false
+		}
+		if user == "" || !embedIDPattern.MatchString(user) {
+			user = "i"
+		}
+		return Embed{Provider: EmbedProviderTwitter, ID: id, URL: "https://twitter.com/" + user + "/status/" + id}, true
+	case "instagram.com":
+		kind, id := segment(0), segment(1)
+		if (kind != "p" && kind != "reel" && kind != "tv") || !embedIDPattern.MatchString(id) {
+			return Embed{}, false
+		}
+		return Embed{Provider: EmbedProviderInstagram, ID: id, URL: "https://www.instagram.com/" + kind + "/" + id + "/"}, true
+	case "codepen.io":
+		if len(segments) < 3 {
+			return Embed{}, false
+		}
+		user, id := segment(0), segment(len(segments)-1)
+		if !embedIDPattern.MatchString(user) || !embedIDPattern.MatchString(id) {
+			return Embed{}, false
+		}
+		return Embed{Provider: EmbedProviderCodePen, ID: id, URL: "https://codepen.io/" + user + "/pen/" + id}, true
+	case "gist.github.com":
+		user, id := segment(0), strings.TrimSuffix(segment(1), ".js")
+		if id == "" {
+			user, id = "", strings.TrimSuffix(segment(0), ".js")
+		}
+		if !embedIDPattern.MatchString(id) {
+			return Embed{}, false
+		}
+		canonical := "https://gist.github.com/" + id
+		if user != "" && embedIDPattern.MatchString(user) {
+			canonical = "https://gist.github.com/" + user + "/" + id
+		}
+		return Embed{Provider: EmbedProviderGist, ID: id, URL: canonical}, true
+	case "open.spotify.com":
+		if segment(0) == "embed" {
+			segments = segments[1:]
+		}
+		kind, id := segment(0), segment(1)
+		if !spotifyKindPattern.MatchString(kind) || !embedIDPattern.MatchString(id) {
+			return Embed{}, false
+		}
+		return Embed{Provider: EmbedProviderSpotify, ID: id, URL: "https://open.spotify.com/" + kind + "/" + id}, true
+	}
+	return Embed{}, false
+}
+
+// embedPlaceholderHTML renders an embed as a paragraph linking to the media, with the embed
+// details in data attributes that survive readability
+func embedPlaceholderHTML(embed Embed) string {
+	attrs := ` data-embed="` + html.EscapeString(embed.Provider) + `" data-embed-id="` + html.EscapeString(embed.ID) +
+		`" data-embed-url="` + html.EscapeString(embed.URL) + `"`
+	if embed.Title != "" {
+		attrs += ` data-embed-title="` + html.EscapeString(embed.Title) + `"`
+	}
+	if embed.Thumbnail != "" {
+		attrs += ` data-embed-thumbnail="` + html.EscapeString(embed.Thumbnail) + `"`
+	}
+	return `<p` + attrs + `><a href="` + html.EscapeString(embed.URL) + `">` + html.EscapeString(embedLabel(embed)) + `</a></p>`
+}
+
+// embedLabel returns the title of an embed, or a description of it when it has none
+func embedLabel(embed Embed) string {
+	if embed.Title != "" {
+		return embed.Title
+	}
+	return embedLabels[embed.Provider]
+}
+
+// embedMarkdown renders an embed placeholder as a thumbnail linking to the media, or as a link
+// when there is no thumbnail or thumbnails are not wanted
+func embedMarkdown(placeholder *goquery.Selection, thumbnails bool) (string, bool) {
+	provider, ok := placeholder.Attr("data-embed")
+	if !ok {
+		return "", false
+	}
+	embed := Embed{
+		Provider:  provider,
+		URL:       placeholder.AttrOr("data-embed-url", ""),
+		Title:     placeholder.AttrOr("data-embed-title", ""),
+		Thumbnail: placeholder.AttrOr("data-embed-thumbnail", ""),
+	}
+	label := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(embedLabel(embed))
+	if thumbnails && embed.Thumbnail != "" {
+		return "\n\n[![" + label + "](" + embed.Thumbnail + ")](" + embed.URL + ")\n\n", true
+	}
+	return "\n\n[" + label + "](" + embed.URL + ")\n\n", true
+}
+
+// collectArticleEmbeds lists the embed placeholders left in the cleaned content
+func (ac *ArticleCleaner) collectArticleEmbeds(content string) []Embed {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		ac.logger.Warnw("Failed to parse article content for embeds", "error", err)
+		return nil
+	}
+
+	var embeds []Embed
+	doc.Find("[data-embed]").Each(func(i int, placeholder *goquery.Selection) {
+		embeds = append(embeds, Embed{
+			Provider:  placeholder.AttrOr("data-embed", ""),
+			ID:        placeholder.AttrOr("data-embed-id", ""),
+			URL:       placeholder.AttrOr("data-embed-url", ""),
+			Title:     placeholder.AttrOr("data-embed-title", ""),
+			Thumbnail: placeholder.AttrOr("data-embed-thumbnail", ""),
+		})
+	})
+	return embeds
+}
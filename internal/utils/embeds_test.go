@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseEmbedURL(t *testing.T) {
+	tests := []struct {
+		url    string
+		want   Embed
+		wantOK bool
+	}{
+		{
+			url:    "https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ?rel=0",
+			want:   Embed{Provider: EmbedProviderYouTube, ID: "dQw4w9WgXcQ", URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", Thumbnail: "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg"},
+			wantOK: true,
+		},
+		{
+			url:    "https://youtu.be/dQw4w9WgXcQ",
+			want:   Embed{Provider: EmbedProviderYouTube, ID: "dQw4w9WgXcQ", URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", Thumbnail: "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg"},
+			wantOK: true,
+		},
+		{
+			url:    "//player.vimeo.com/video/76979871?h=8272103f6e",
+			want:   Embed{Provider: EmbedProviderVimeo, ID: "76979871", URL: "https://vimeo.com/76979871"},
+			wantOK: true,
+		},
+		{
+			url:    "https://platform.twitter.com/embed/Tweet.html?id=1445078208190291968",
+			want:   Embed{Provider: EmbedProviderTwitter, ID: "1445078208190291968", URL: "https://twitter.com/i/status/1445078208190291968"},
+			wantOK: true,
+		},
+		{
+			url:    "https://x.com/golang/status/1445078208190291968?ref_src=twsrc",
+			want:   Embed{Provider: EmbedProviderTwitter, ID: "1445078208190291968", URL: "https://twitter.com/golang/status/1445078208190291968"},
+			wantOK: true,
+		},
+		{
+			url:    "https://www.instagram.com/reel/CxYz123AbC/embed/captioned",
+			want:   Embed{Provider: EmbedProviderInstagram, ID: "CxYz123AbC", URL: "https://www.instagram.com/reel/CxYz123AbC/"},
+			wantOK: true,
+		},
+		{
+			url:    "https://codepen.io/chriscoyier/embed/preview/gfdDu",
+			want:   Embed{Provider: EmbedProviderCodePen, ID: "gfdDu", URL: "https://codepen.io/chriscoyier/pen/gfdDu"},
+			wantOK: true,
+		},
+		{
+			url:    "https://gist.github.com/octocat/6cad326836d38bd3a7ae.js",
+			want:   Embed{Provider: EmbedProviderGist, ID: "6cad326836d38bd3a7ae", URL: "https://gist.github.com/octocat/6cad326836d38bd3a7ae"},
+			wantOK: true,
+		},
+		{
+			url:    "https://open.spotify.com/embed/episode/4rOoJ6Egrf8K2IrywzwOMk?utm_source=generator",
+			want:   Embed{Provider: EmbedProviderSpotify, ID: "4rOoJ6Egrf8K2IrywzwOMk", URL: "https://open.spotify.com/episode/4rOoJ6Egrf8K2IrywzwOMk"},
+			wantOK: true,
+		},
+		{url: "https://www.youtube.com/channel/UC123"},
+		{url: "https://codepen.io/"},
+		{url: "https://example.com/embed/video/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, ok := parseEmbedURL(tt.url)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEmbedURL() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReplaceEmbeds(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "wrapped youtube iframe",
+			html: `<div class="embed embed-youtube"><iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ" title="Never Gonna Give You Up"></iframe></div>`,
+			want: `<p data-embed="youtube" data-embed-id="dQw4w9WgXcQ" data-embed-url="https://www.youtube.com/watch?v=dQw4w9WgXcQ" data-embed-title="Never Gonna Give You Up" data-embed-thumbnail="https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg"><a href="https://www.youtube.com/watch?v=dQw4w9WgXcQ">Never Gonna Give You Up</a></p>`,
+		},
+		{
+			name: "tweet",
+			html: `<blockquote class="twitter-tweet"><p lang="en">Go 1.23 is released!</p>— Go (@golang) <a href="https://twitter.com/golang/status/1821573476337901778?ref_src=twsrc%5Etfw">August 13, 2024</a></blockquote>`,
+			want: `<p data-embed="twitter" data-embed-id="1821573476337901778" data-embed-url="https://twitter.com/golang/status/1821573476337901778" data-embed-title="Go 1.23 is released!"><a href="https://twitter.com/golang/status/1821573476337901778">Go 1.23 is released!</a></p>`,
+		},
+		{
+			name: "gist script",
+			html: `<script src="https://gist.github.com/octocat/6cad326836d38bd3a7ae.js"></script>`,
+			want: `<p data-embed="gist" data-embed-id="6cad326836d38bd3a7ae" data-embed-url="https://gist.github.com/octocat/6cad326836d38bd3a7ae"><a href="https://gist.github.com/octocat/6cad326836d38bd3a7ae">GitHub Gist</a></p>`,
+		},
+		{
+			name: "codepen",
+			html: `<p class="codepen" data-slug-hash="gfdDu" data-user="chriscoyier"><span>See the Pen <a href="https://codepen.io/chriscoyier/pen/gfdDu">Pen</a></span></p>`,
+			want: `<p data-embed="codepen" data-embed-id="gfdDu" data-embed-url="https://codepen.io/chriscoyier/pen/gfdDu" data-embed-title="Pen"><a href="https://codepen.io/chriscoyier/pen/gfdDu">Pen</a></p>`,
+		},
+		{
+			name: "unknown iframe",
+			html: `<iframe src="https://example.com/widget"></iframe>`,
+			want: `<iframe src="https://example.com/widget"></iframe>`,
+		},
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<body>" + tt.html + "</body>"))
+			if err != nil {
+				t.Fatalf("Failed to parse HTML: %v", err)
+			}
+			ac.replaceEmbeds(doc)
+			got, _ := doc.Find("body").Html()
+			if got != tt.want {
+				t.Errorf("replaceEmbeds() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanArticleEmbeds(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>Watch This</title></head>
+<body>
+	<article>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<div class="video-player"><iframe src="https://player.vimeo.com/video/76979871" title="The New Vimeo Player"></iframe></div>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+		<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ"></iframe>
+		<p>A closing paragraph that keeps the video inside the article body for readability.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	want := []Embed{
+		{Provider: EmbedProviderVimeo, ID: "76979871", URL: "https://vimeo.com/76979871", Title: "The New Vimeo Player"},
+		{Provider: EmbedProviderYouTube, ID: "dQw4w9WgXcQ", URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", Thumbnail: "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg"},
+	}
+	if !reflect.DeepEqual(article.Embeds, want) {
+		t.Errorf("Embeds = %+v, want %+v", article.Embeds, want)
+	}
+	for _, markdown := range []string{
+		"[The New Vimeo Player](https://vimeo.com/76979871)",
+		"[![YouTube video](https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg)](https://www.youtube.com/watch?v=dQw4w9WgXcQ)",
+	} {
+		if !strings.Contains(article.Markdown, markdown) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", markdown, article.Markdown)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultExcerptLength is the excerpt length in user-perceived characters
+const defaultExcerptLength = 200
+
+// excerptEllipsis marks an excerpt that was cut
+const excerptEllipsis = "..."
+
+// clausePunctuation ends a clause; excerpts in scripts without spaces prefer to break after it
+const clausePunctuation = ",;:、，；："
+
+// generateExcerpt shortens content to at most maxLength grapheme clusters, never splitting a
+// character. It breaks after the last sentence end in the second half of the limit, else at the
+// last space, else after clause punctuation, and only falls back to a cluster boundary for
+// scripts written without spaces such as Chinese, Japanese and Thai.
+func (ac *ArticleCleaner) generateExcerpt(content string, maxLength int) string {
+	if maxLength <= 0 {
+		maxLength = defaultExcerptLength
+	}
+	content = strings.Join(strings.Fields(content), " ")
+
+	clusters := graphemeClusters(content)
+	if len(clusters) <= maxLength {
+		return content
+	}
+
+	// Limits too short to hold the ellipsis are cut without one
+	ellipsis := excerptEllipsis
+	limit := maxLength - utf8.RuneCountInString(ellipsis)
+	if limit < 1 {
+		ellipsis, limit = "", maxLength
+	}
+	clusters = clusters[:limit]
+	minimum := limit / 2
+
+	// Complete sentences need no ellipsis
+	for i := len(clusters) - 1; i >= minimum; i-- {
+		if r := firstRune(clusters[i]); isSentenceTerminator(r) && (i+1 == len(clusters) || clusters[i+1] == " " || isCJKTerminator(r)) {
+			return strings.Join(clusters[:i+1], "")
+		}
+	}
+
+	end := len(clusters)
+	if i := lastClusterIndex(clusters, minimum, func(c string) bool { return c == " " }); i >= 0 {
+		end = i
+	} else if i := lastClusterIndex(clusters, minimum, func(c string) bool { return strings.Contains(clausePunctuation, c) }); i >= 0 {
+		end = i + 1
+	}
+
+	excerpt := strings.TrimRightFunc(strings.Join(clusters[:end], ""), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(clausePunctuation, r)
+	})
+	return excerpt + ellipsis
+}
+
+// lastClusterIndex returns the index of the last cluster at or after minimum matching the predicate, or -1
+func lastClusterIndex(clusters []string, minimum int, match func(string) bool) int {
+	for i := len(clusters) - 1; i >= minimum; i-- {
+		if match(clusters[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// firstRune returns the first rune of s
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// graphemeClusters splits text into user-perceived characters, approximating the UAX #29
+// extended grapheme cluster rules: combining marks, variation selectors, emoji modifiers and
+// tags, zero width joiner sequences, Indic conjuncts, regional indicator pairs and Hangul
+// syllable sequences stay together
+func graphemeClusters(text string) []string {
+	var clusters []string
+	start := 0
+	var previous rune
+	regionalIndicators := 0
+
+	for i, r := range text {
+		join := i > 0 && ((previous == '\r' && r == '\n') ||
+			isGraphemeExtender(r) ||
+			previous == '\u200d' ||
+			(isVirama(previous) && unicode.IsLetter(r)) ||
+			(isRegionalIndicator(r) && regionalIndicators%2 == 1) ||
+			joinsHangul(previous, r))
+
+		if i > 0 && !join {
+			clusters = append(clusters, text[start:i])
+			start = i
+		}
+		if isRegionalIndicator(r) {
+			if join {
+				regionalIndicators++
+			} else {
+				regionalIndicators = 1
+			}
+		} else if !join {
+			regionalIndicators = 0
+		}
+		previous = r
+	}
+	if start < len(text) {
+		clusters = append(clusters, text[start:])
+	}
+	return clusters
+}
+
+// isGraphemeExtender reports whether r attaches to the preceding character
+func isGraphemeExtender(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == '\u200d' || r == 'ำ' || r == 'ຳ' ||
+		(r >= 0xFE00 && r <= 0xFE0F) || (r >= 0xE0100 && r <= 0xE01EF) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) ||
+		(r >= 0xE0020 && r <= 0xE007F)
+}
+
+// isVirama reports whether r is an Indic virama, which joins the following consonant into a conjunct
+func isVirama(r rune) bool {
+	switch r {
+	case '\u094D', '\u09CD', '\u0A4D', '\u0ACD', '\u0B4D', '\u0BCD', '\u0C4D', '\u0CCD', '\u0D4D':
+		return true
+	}
+	return false
+}
+
+// isRegionalIndicator reports whether r is a regional indicator symbol, pairs of which form flags
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// Hangul syllable types used by joinsHangul
+const (
+	hangulNone = iota
+	hangulL
+	hangulV
+	hangulT
+	hangulLV
+	hangulLVT
+)
+
+// hangulType classifies r as a Hangul leading consonant, vowel, trailing consonant or syllable
+func hangulType(r rune) int {
+	switch {
+	case (r >= 0x1100 && r <= 0x115F) || (r >= 0xA960 && r <= 0xA97C):
+		return hangulL
+	case (r >= 0x1160 && r <= 0x11A7) || (r >= 0xD7B0 && r <= 0xD7C6):
+		return hangulV
+	case (r >= 0x11A8 && r <= 0x11FF) || (r >= 0xD7CB && r <= 0xD7FB):
+		return hangulT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return hangulLV
+		}
+		return hangulLVT
+	}
+	return hangulNone
+}
+
+// joinsHangul reports whether two Hangul jamo or syllables belong to one syllable block
+func joinsHangul(previous, r rune) bool {
+	a, b := hangulType(previous), hangulType(r)
+	switch a {
+	case hangulL:
+		return b == hangulL || b == hangulV || b == hangulLV || b == hangulLVT
+	case hangulLV, hangulV:
+		return b == hangulV || b == hangulT
+	case hangulLVT, hangulT:
+		return b == hangulT
+	}
+	return false
+}
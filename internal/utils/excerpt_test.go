@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGraphemeClusters(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"combining accent", "éa", []string{"é", "a"}},
+		{"zwj family", "👨‍👩‍👧!", []string{"👨‍👩‍👧", "!"}},
+		{"flags", "🇯🇵🇫🇷🇩", []string{"🇯🇵", "🇫🇷", "🇩"}},
+		{"skin tone", "👍🏽👍", []string{"👍🏽", "👍"}},
+		{"variation selector", "❤️x", []string{"❤️", "x"}},
+		{"devanagari conjunct", "नमस्ते", []string{"न", "म", "स्ते"}},
+		{"hangul jamo", "각ᄀ", []string{"각", "ᄀ"}},
+		{"thai sara am", "คำถาม", []string{"คำ", "ถ", "า", "ม"}},
+		{"crlf", "a\r\nb", []string{"a", "\r\n", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := graphemeClusters(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("graphemeClusters(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateExcerpt(t *testing.T) {
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	tests := []struct {
+		name      string
+		content   string
+		maxLength int
+		want      string
+	}{
+		{
+			name:      "short content is kept",
+			content:   "A short\n\nparagraph.",
+			maxLength: 200,
+			want:      "A short paragraph.",
+		},
+		{
+			name:      "english sentence",
+			content:   "The quick brown fox jumps over the lazy dog. It was not amused by this at all, really.",
+			maxLength: 60,
+			want:      "The quick brown fox jumps over the lazy dog.",
+		},
+		{
+			name:      "english word",
+			content:   "The quick brown fox jumps over the lazy dog and keeps running through the forest.",
+			maxLength: 40,
+			want:      "The quick brown fox jumps over the...",
+		},
+		{
+			name:      "hindi",
+			content:   "नमस्ते दुनिया। यह एक परीक्षण वाक्य है जो हिंदी में लिखा गया है और काफी लंबा है।",
+			maxLength: 30,
+			want:      "नमस्ते दुनिया। यह एक परीक्षण वाक्य है जो...",
+		},
+		{
+			name:      "chinese sentence",
+			content:   "今天天气很好，我们去公园散步吧。然后我们去吃饭，晚上看电影。",
+			maxLength: 20,
+			want:      "今天天气很好，我们去公园散步吧。",
+		},
+		{
+			name:      "japanese clause",
+			content:   "東京は日本の首都であり、世界最大級の都市の一つです。人口は約千四百万人です。",
+			maxLength: 20,
+			want:      "東京は日本の首都であり...",
+		},
+		{
+			name:      "chinese without punctuation",
+			content:   strings.Repeat("汉字", 20),
+			maxLength: 10,
+			want:      "汉字汉字汉字汉...",
+		},
+		{
+			name:      "emoji",
+			content:   "Family 👨‍👩‍👧‍👦 and flags 🇯🇵🇫🇷 with skin 👍🏽 tones everywhere here",
+			maxLength: 20,
+			want:      "Family 👨‍👩‍👧‍👦 and...",
+		},
+		{
+			name:      "korean",
+			content:   "한국어 문장은 띄어쓰기를 사용합니다. 그래서 단어 경계에서 자를 수 있습니다.",
+			maxLength: 20,
+			want:      "한국어 문장은 띄어쓰기를...",
+		},
+		{
+			name:      "thai",
+			content:   "ภาษาไทยไม่มีการเว้นวรรคระหว่างคำ แต่มีการเว้นวรรคระหว่างประโยค เพื่อความชัดเจน",
+			maxLength: 30,
+			want:      "ภาษาไทยไม่มีการเว้นวรรคระหว่างคำ...",
+		},
+		{
+			name:      "arabic",
+			content:   "مرحبا بالعالم. هذه جملة اختبار طويلة باللغة العربية لاختبار المقتطف؟ نعم.",
+			maxLength: 30,
+			want:      "مرحبا بالعالم.",
+		},
+		{
+			name:      "limit too short for the ellipsis",
+			content:   "Hello world",
+			maxLength: 3,
+			want:      "Hel",
+		},
+		{
+			name:      "single character limit",
+			content:   "👍🏽 great",
+			maxLength: 1,
+			want:      "👍🏽",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ac.generateExcerpt(tt.content, tt.maxLength)
+			if got != tt.want {
+				t.Errorf("generateExcerpt() = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("generateExcerpt() returned invalid UTF-8: %q", got)
+			}
+			if n := len(graphemeClusters(got)); n > tt.maxLength {
+				t.Errorf("generateExcerpt() returned %d characters, more than %d", n, tt.maxLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Footnote is a footnote or reference of the article
+type Footnote struct {
+	// Label is the markdown footnote label, e.g. "1" for [^1]
+	Label string `json:"label"`
+	// Text is the plain text of the footnote definition
+	Text string `json:"text"`
+	html string
+}
+
+// footnoteReferenceSelectors match in-text links to footnotes (Wikipedia, Pandoc, kramdown,
+// Hugo, markdown-it, Substack, WordPress footnote plugins)
+var footnoteReferenceSelectors = []string{
+	`sup a[href^="#"]`, `a.footnote-ref[href^="#"]`, `a.footnote-anchor[href^="#"]`,
+	`a[rel="footnote"][href^="#"]`, `a[role="doc-noteref"][href^="#"]`,
+}
+
+// footnoteContainerSelectors match the sections holding footnote definitions
+var footnoteContainerSelectors = []string{
+	".footnotes", ".footnote", ".footnotes-list", ".references", ".reflist", `[role="doc-endnotes"]`,
+}
+
+// footnoteDefinitionSelectors match a single footnote definition around the link target
+var footnoteDefinitionSelectors = []string{"li", ".footnote", `[role="doc-endnote"]`, `[role="doc-footnote"]`}
+
+// footnoteBacklinkSelectors match the links from a definition back to its references
+var footnoteBacklinkSelectors = []string{
+	".reversefootnote", ".footnote-back", ".footnote-backref", ".footnote-number", ".mw-cite-backlink",
+	`[role="doc-backlink"]`,
+}
+
+// footnoteContentSelectors match the definition text inside wrappers that also hold backlinks
+var footnoteContentSelectors = []string{".reference-text", ".footnote-content"}
+
+// footnoteIDPattern matches ids commonly given to footnote definitions
+var footnoteIDPattern = regexp.MustCompile(`(?i)^(?:fn|footnote|cite[_-]note|note|endnote|ftn)`)
+
+// extractFootnotes replaces footnote references with markers and removes their definitions from
+// the document, returning the definitions in order of first reference. It runs before
+// removeUnwantedElements, since footnotes often live in a <footer>.
+func (ac *ArticleCleaner) extractFootnotes(doc *goquery.Document) []Footnote {
+	containers := strings.Join(footnoteContainerSelectors, ", ")
+	labels := make(map[string]string)
+	var footnotes []Footnote
+	var definitions []*goquery.Selection
+	var referenceIDs []string
+
+	doc.Find(strings.Join(footnoteReferenceSelectors, ", ")).Each(func(i int, link *goquery.Selection) {
+		if link.ParentsFiltered(containers).Length() > 0 {
+			return
+		}
+		id := strings.TrimPrefix(link.AttrOr("href", ""), "#")
+		if id == "" || strings.ContainsAny(id, `"\`) {
+			return
+		}
+		target := doc.Find(`[id="` + id + `"]`).First()
+		if target.Length() == 0 || (!footnoteIDPattern.MatchString(id) && target.Closest(containers).Length() == 0) {
+			return
+		}
+
+		label, ok := labels[id]
+		if !ok {
+			definition := target.Closest(strings.Join(footnoteDefinitionSelectors, ", "))
+			if definition.Length() == 0 && target.Closest(containers).Length() > 0 {
+				definition = target
+			}
+			if definition.Length() == 0 {
+				return
+			}
+			label = strconv.Itoa(len(footnotes) + 1)
+			labels[id] = label
+			footnotes = append(footnotes, Footnote{Label: label})
+			definitions = append(definitions, definition)
+		}
+
+		// The marker replaces the whole superscript when the link is all it holds
+		reference := link
+		if parent := link.Parent(); goquery.NodeName(parent) == "sup" && strings.TrimSpace(parent.Text()) == strings.TrimSpace(link.Text()) {
+			reference = parent
+		}
+		for _, s := range []*goquery.Selection{reference, link} {
+			if refID, ok := s.Attr("id"); ok {
+				referenceIDs = append(referenceIDs, refID)
+			}
+		}
+		reference.ReplaceWithHtml(`<sup data-footnote="` + label + `">[` + label + `]</sup>`)
+	})
+
+	for i, definition := range definitions {
+		footnotes[i].Text, footnotes[i].html = footnoteDefinition(definition, referenceIDs)
+		list := definition.Parent()
+		definition.Remove()
+		if list.Is("ol, ul") && list.Children().Length() == 0 {
+			list.Remove()
+		}
+	}
+
+	// Drop containers emptied by moving their definitions
+	doc.Find(containers).Each(func(i int, container *goquery.Selection) {
+		if strings.TrimSpace(container.Text()) == "" {
+			container.Remove()
+		}
+	})
+
+	if len(footnotes) > 0 {
+		ac.logger.Debugw("Extracted footnotes", "count", len(footnotes))
+	}
+	return footnotes
+}
+
+// footnoteDefinition returns the text and HTML of a footnote definition without its backlinks
+func footnoteDefinition(definition *goquery.Selection, referenceIDs []string) (string, string) {
+	definition = definition.Clone()
+	definition.Find(strings.Join(footnoteBacklinkSelectors, ", ")).Remove()
+	for _, id := range referenceIDs {
+		definition.Find(`a[href="#` + id + `"]`).Remove()
+	}
+	if content := definition.Find(strings.Join(footnoteContentSelectors, ", ")).First(); content.Length() > 0 {
+		definition = content
+	}
+
+	html, _ := definition.Html()
+	return strings.Join(strings.Fields(definition.Text()), " "), strings.TrimSpace(html)
+}
+
+// resolveFootnoteURLs resolves the links and media of footnote definitions, which leave the
+// document before its URLs are resolved, and removes tracking parameters when rewrite is set
+func (ac *ArticleCleaner) resolveFootnoteURLs(footnotes []Footnote, baseURL *url.URL, trackingParams []string, rewrite bool) {
+	for i := range footnotes {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(footnotes[i].html))
+		if err != nil {
+			ac.logger.Warnw("Failed to parse footnote for links", "label", footnotes[i].Label, "error", err)
+			continue
+		}
+		ac.resolveDocumentURLs(doc, baseURL)
+		if rewrite {
+			ac.rewriteTrackingLinks(doc, trackingParams)
+		}
+		if html, err := doc.Find("body").Html(); err == nil {
+			footnotes[i].html = strings.TrimSpace(html)
+		}
+	}
+}
+
+// footnotesHTML joins the HTML of footnote definitions, so their links are collected with the article's
+func footnotesHTML(footnotes []Footnote) string {
+	var b strings.Builder
+	for _, footnote := range footnotes {
+		b.WriteString(footnote.html)
+	}
+	return b.String()
+}
+
+// filterFootnotes keeps the footnotes whose references survived readability
+func filterFootnotes(footnotes []Footnote, content string) []Footnote {
+	var kept []Footnote
+	for _, footnote := range footnotes {
+		if strings.Contains(content, `data-footnote="`+footnote.Label+`"`) {
+			kept = append(kept, footnote)
+		}
+	}
+	return kept
+}
+
+// renderFootnotesMarkdown renders footnote definitions for the end of the markdown, indenting
+// continuation lines so multi-paragraph definitions stay in their footnote
+func (ac *ArticleCleaner) renderFootnotesMarkdown(footnotes []Footnote, options MarkdownOptions) string {
+	converter := ac.markdownConverter(options)
+	var b strings.Builder
+	for _, footnote := range footnotes {
+		markdown, err := converter.ConvertString(footnote.html)
+		if err != nil {
+			ac.logger.Warnw("Failed to convert footnote to markdown", "label", footnote.Label, "error", err)
+			markdown = footnote.Text
+		}
+		lines := strings.Split(strings.TrimSpace(markdown), "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = "    " + lines[i]
+			}
+		}
+		b.WriteString("\n\n" + wrapMarkdown("[^"+footnote.Label+"]: "+strings.Join(lines, "\n"), options.WrapWidth))
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractFootnotes(t *testing.T) {
+	tests := []struct {
+		name      string
+		html      string
+		wantBody  string
+		wantTexts []string
+	}{
+		{
+			name: "kramdown",
+			html: `<p>Claim<sup id="fnref:1" role="doc-noteref"><a href="#fn:1" class="footnote" rel="footnote">1</a></sup> and again<sup id="fnref:1:1"><a href="#fn:1" class="footnote">1</a></sup>.</p>
+<div class="footnotes" role="doc-endnotes"><ol><li id="fn:1" role="doc-endnote"><p>First source. <a href="#fnref:1" class="reversefootnote" role="doc-backlink">↩</a> <a href="#fnref:1:1" class="reversefootnote">↩<sup>2</sup></a></p></li></ol></div>`,
+			wantBody:  `<p>Claim<sup data-footnote="1">[1]</sup> and again<sup data-footnote="1">[1]</sup>.</p>`,
+			wantTexts: []string{"First source."},
+		},
+		{
+			name: "pandoc",
+			html: `<p>One<a href="#fn1" class="footnote-ref" id="fnref1" role="doc-noteref"><sup>1</sup></a> two<a href="#fn2" class="footnote-ref" id="fnref2" role="doc-noteref"><sup>2</sup></a>.</p>
+<section class="footnotes" role="doc-endnotes"><hr/><ol><li id="fn1" role="doc-endnote"><p>Note one.<a href="#fnref1" class="footnote-back" role="doc-backlink">↩︎</a></p></li><li id="fn2" role="doc-endnote"><p>Note <em>two</em>.<a href="#fnref2" class="footnote-back" role="doc-backlink">↩︎</a></p></li></ol></section>`,
+			wantBody:  `<p>One<sup data-footnote="1">[1]</sup> two<sup data-footnote="2">[2]</sup>.</p>`,
+			wantTexts: []string{"Note one.", "Note two."},
+		},
+		{
+			name: "wikipedia",
+			html: `<p>Fact.<sup id="cite_ref-smith_1-0" class="reference"><a href="#cite_note-smith-1">[1]</a></sup></p>
+<div class="reflist"><ol class="references"><li id="cite_note-smith-1"><span class="mw-cite-backlink"><b><a href="#cite_ref-smith_1-0">^</a></b></span> <span class="reference-text">Smith, J. (2020). <i>Facts</i>.</span></li></ol></div>`,
+			wantBody:  `<p>Fact.<sup data-footnote="1">[1]</sup></p>`,
+			wantTexts: []string{"Smith, J. (2020). Facts."},
+		},
+		{
+			name: "substack",
+			html: `<p>Text<a class="footnote-anchor" id="footnote-anchor-1" href="#footnote-1">1</a></p>
+<div class="footnote"><a id="footnote-1" href="#footnote-anchor-1" class="footnote-number">1</a><div class="footnote-content"><p>Substack note.</p></div></div>`,
+			wantBody:  `<p>Text<sup data-footnote="1">[1]</sup></p>`,
+			wantTexts: []string{"Substack note."},
+		},
+		{
+			name:     "superscript links to sections are left alone",
+			html:     `<p>See<sup><a href="#notes">notes</a></sup>.</p><h2 id="notes">Notes</h2>`,
+			wantBody: `<p>See<sup><a href="#notes">notes</a></sup>.</p><h2 id="notes">Notes</h2>`,
+		},
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<body>" + tt.html + "</body>"))
+			if err != nil {
+				t.Fatalf("Failed to parse HTML: %v", err)
+			}
+			footnotes := ac.extractFootnotes(doc)
+
+			got, _ := doc.Find("body").Html()
+			if got = strings.TrimSpace(got); got != tt.wantBody {
+				t.Errorf("body =\n%s\nwant\n%s", got, tt.wantBody)
+			}
+			var texts []string
+			for i, footnote := range footnotes {
+				if want := []string{"1", "2"}[i]; footnote.Label != want {
+					t.Errorf("Label = %q, want %q", footnote.Label, want)
+				}
+				texts = append(texts, footnote.Text)
+			}
+			if !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("footnote texts = %q, want %q", texts, tt.wantTexts)
+			}
+		})
+	}
+}
+
+func TestRenderFootnotesMarkdown(t *testing.T) {
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	footnotes := []Footnote{
+		{Label: "1", html: `See <a href="https://example.com/">the source</a>.`},
+		{Label: "2", html: `<p>First paragraph.</p><p>Second paragraph.</p>`},
+	}
+	want := "\n\n[^1]: See [the source](https://example.com/).\n\n[^2]: First paragraph.\n\n    Second paragraph."
+	if got := ac.renderFootnotesMarkdown(footnotes, DefaultMarkdownOptions()); got != want {
+		t.Errorf("renderFootnotesMarkdown() = %q, want %q", got, want)
+	}
+}
+
+func TestCleanArticleFootnotes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>Footnoted</title></head>
+<body>
+	<article>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs<sup class="footnote-ref"><a href="#fn1" id="fnref1">[1]</a></sup> to pass the
+		content length threshold that readability uses to determine if something is actual article content.</p>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+		<footer>
+			<section class="footnotes"><ol class="footnotes-list">
+				<li id="fn1" class="footnote-item"><p>Readability scores candidate nodes, see <a href="/docs/scoring?utm_source=notes">the docs</a>. <a href="#fnref1" class="footnote-backref">↩︎</a></p></li>
+			</ol></section>
+		</footer>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	options := DefaultArticleOptions()
+	options.RewriteLinks = true
+	article, err := ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+
+	if want := []Footnote{{Label: "1", Text: "Readability scores candidate nodes, see the docs."}}; len(article.Footnotes) != 1 || article.Footnotes[0].Label != want[0].Label || article.Footnotes[0].Text != want[0].Text {
+		t.Errorf("Footnotes = %+v, want %+v", article.Footnotes, want)
+	}
+	if !strings.Contains(article.Markdown, "multiple paragraphs[^1] to pass") {
+		t.Errorf("Expected a footnote reference in the markdown, got:\n%s", article.Markdown)
+	}
+	if !strings.HasSuffix(article.Markdown, "\n\n[^1]: Readability scores candidate nodes, see [the docs]("+ts.URL+"/docs/scoring).") {
+		t.Errorf("Expected the footnote definition with a resolved link at the end of the markdown, got:\n%s", article.Markdown)
+	}
+	if !slices.ContainsFunc(article.Links, func(link ArticleLink) bool { return link.URL == ts.URL+"/docs/scoring" }) {
+		t.Errorf("Expected the footnote link among the article links, got %+v", article.Links)
+	}
+}
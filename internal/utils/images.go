@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ArticleImage describes an image kept in the cleaned article
+type ArticleImage struct {
+	URL     string `json:"url"`
+	Alt     string `json:"alt,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Caption string `json:"caption,omitempty"`
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+	// Position is the zero-based order of the image in the article, or -1 when the image only
+	// appears in the page metadata
+	Position int  `json:"position"`
+	Lead     bool `json:"lead,omitempty"`
+	// Alternatives are the srcset and <source> candidates that were not chosen
+	Alternatives []ImageCandidate `json:"alternatives,omitempty"`
+}
+
+// leadImageMinWidth is the smallest width of an in-content image considered for the lead image
+const leadImageMinWidth = 400
+
+// collectArticleImages lists the images of the cleaned article content in document order and
+// marks the lead image
+func (ac *ArticleCleaner) collectArticleImages(content string, selections map[string]*imageSelection, og *OpenGraphData) []ArticleImage {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		ac.logger.Warnw("Failed to parse article content for images", "error", err)
+		return nil
+	}
+
+	var images []ArticleImage
+	seen := make(map[string]bool)
+	doc.Find("img[src]").Each(func(i int, img *goquery.Selection) {
+		src := strings.TrimSpace(img.AttrOr("src", ""))
+		if src == "" || seen[src] {
+			return
+		}
+		seen[src] = true
+
+		image := ArticleImage{
+			URL:      src,
+			Alt:      strings.TrimSpace(img.AttrOr("alt", "")),
+			Title:    strings.TrimSpace(img.AttrOr("title", "")),
+			Caption:  imageCaption(img),
+			Width:    imageDimension(img.AttrOr("width", "")),
+			Height:   imageDimension(img.AttrOr("height", "")),
+			Position: len(images),
+		}
+		if selection, ok := selections[src]; ok {
+			image.Alternatives = selection.alternatives
+			if image.Width == 0 && selection.chosen.Width > 0 {
+				// A width descriptor gives the intrinsic width of the chosen file
+				image.Width = selection.chosen.Width
+			}
+		}
+		images = append(images, image)
+	})
+
+	ogImage := ""
+	if og != nil {
+		ogImage = og.Image
+	}
+	images = markLeadImage(images, ogImage)
+
+	if len(images) > 0 {
+		ac.logger.Debugw("Collected article images", "count", len(images))
+	}
+
+	return images
+}
+
+// markLeadImage marks the lead image: the in-content copy of og:image, else the first large
+// in-content image, else og:image itself, else the first image not known to be small
+func markLeadImage(images []ArticleImage, ogImage string) []ArticleImage {
+	if ogImage != "" {
+		for i := range images {
+			if sameImage(images[i], ogImage) {
+				images[i].Lead = true
+				return images
+			}
+		}
+	}
+
+	for i := range images {
+		if images[i].Width >= leadImageMinWidth {
+			images[i].Lead = true
+			return images
+		}
+	}
+
+	if ogImage != "" {
+		return append([]ArticleImage{{URL: ogImage, Position: -1, Lead: true}}, images...)
+	}
+
+	for i := range images {
+		if images[i].Width == 0 {
+			images[i].Lead = true
+			return images
+		}
+	}
+
+	return images
+}
+
+// sameImage reports whether imageURL refers to the image or one of its alternatives, ignoring
+// the scheme and query string that CDNs use for resizing
+func sameImage(image ArticleImage, imageURL string) bool {
+	target := imageIdentity(imageURL)
+	if target == "" {
+		return false
+	}
+	if imageIdentity(image.URL) == target {
+		return true
+	}
+	for _, alternative := range image.Alternatives {
+		if imageIdentity(alternative.URL) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// imageIdentity reduces an image URL to its host and path
+func imageIdentity(imageURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(imageURL))
+	if err != nil || parsed.Path == "" {
+		return ""
+	}
+	return strings.ToLower(parsed.Host) + parsed.Path
+}
+
+// imageCaption returns the figcaption text of the figure enclosing img
+func imageCaption(img *goquery.Selection) string {
+	figure := img.Closest("figure")
+	if figure.Length() == 0 {
+		return ""
+	}
+	return strings.Join(strings.Fields(figure.Find("figcaption").First().Text()), " ")
+}
+
+// imageDimension parses a width or height attribute such as "640" or "640px"
+func imageDimension(value string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "px"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCleanArticleImages(t *testing.T) {
+	imagesHTML := `<html>
+<head>
+	<title>Images</title>
+	<meta property="og:image" content="https://cdn.example.com/photos/harbour.jpg?w=1200&h=630" />
+</head>
+<body>
+	<article>
+		<h1>Images</h1>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<figure>
+			<img src="/img/chart.png" alt="Quarterly chart" width="320" height="200">
+			<figcaption>Revenue by   quarter,
+			2024</figcaption>
+		</figure>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+		<figure>
+			<img src="https://cdn.example.com/photos/harbour.jpg" alt="The harbour at dawn" title="Harbour" width="1024px" height="683">
+			<figcaption>The harbour at dawn. Photo: A. Photographer</figcaption>
+		</figure>
+		<p>And a third paragraph discussing the architecture decisions that were made during
+		the design phase of this particular system component.</p>
+		<img src="/img/inline.jpg" alt="Inline">
+	</article>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(imagesHTML))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	want := []ArticleImage{
+		{URL: ts.URL + "/img/chart.png", Alt: "Quarterly chart", Caption: "Revenue by quarter, 2024", Width: 320, Height: 200, Position: 0},
+		{URL: "https://cdn.example.com/photos/harbour.jpg", Alt: "The harbour at dawn", Title: "Harbour", Caption: "The harbour at dawn. Photo: A. Photographer", Width: 1024, Height: 683, Position: 1, Lead: true},
+		{URL: ts.URL + "/img/inline.jpg", Alt: "Inline", Position: 2},
+	}
+	if len(article.Images) != len(want) {
+		t.Fatalf("Expected %d images, got %d: %+v", len(want), len(article.Images), article.Images)
+	}
+	for i, image := range article.Images {
+		if image.URL != want[i].URL || image.Alt != want[i].Alt || image.Title != want[i].Title ||
+			image.Caption != want[i].Caption || image.Width != want[i].Width || image.Height != want[i].Height ||
+			image.Position != want[i].Position || image.Lead != want[i].Lead {
+			t.Errorf("Image %d = %+v, want %+v", i, image, want[i])
+		}
+	}
+}
+
+func TestMarkLeadImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		images   []ArticleImage
+		ogImage  string
+		wantLead string
+		wantLen  int
+	}{
+		{
+			name:     "og:image found in content",
+			images:   []ArticleImage{{URL: "https://example.com/a.jpg", Width: 800}, {URL: "http://example.com/b.jpg"}},
+			ogImage:  "https://example.com/b.jpg?resize=1200",
+			wantLead: "http://example.com/b.jpg",
+			wantLen:  2,
+		},
+		{
+			name: "og:image matches an alternative",
+			images: []ArticleImage{{URL: "https://example.com/a.jpg", Alternatives: []ImageCandidate{
+				{URL: "https://example.com/a.webp", Width: 1600},
+			}}},
+			ogImage:  "https://example.com/a.webp",
+			wantLead: "https://example.com/a.jpg",
+			wantLen:  1,
+		},
+		{
+			name:     "first large image when og:image is elsewhere",
+			images:   []ArticleImage{{URL: "https://example.com/icon.png", Width: 64}, {URL: "https://example.com/photo.jpg", Width: 1200}},
+			ogImage:  "https://example.com/logo.png",
+			wantLead: "https://example.com/photo.jpg",
+			wantLen:  2,
+		},
+		{
+			name:     "og:image added when no large image",
+			images:   []ArticleImage{{URL: "https://example.com/icon.png", Width: 64}},
+			ogImage:  "https://example.com/share.png",
+			wantLead: "https://example.com/share.png",
+			wantLen:  2,
+		},
+		{
+			name:     "first image of unknown size without og:image",
+			images:   []ArticleImage{{URL: "https://example.com/icon.png", Width: 64}, {URL: "https://example.com/photo.jpg"}},
+			wantLead: "https://example.com/photo.jpg",
+			wantLen:  2,
+		},
+		{
+			name:    "no images",
+			wantLen: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images := markLeadImage(tt.images, tt.ogImage)
+			if len(images) != tt.wantLen {
+				t.Fatalf("Expected %d images, got %d", tt.wantLen, len(images))
+			}
+			lead := ""
+			for _, image := range images {
+				if image.Lead {
+					if lead != "" {
+						t.Fatalf("Expected a single lead image, got %+v", images)
+					}
+					lead = image.URL
+				}
+			}
+			if lead != tt.wantLead {
+				t.Errorf("Lead image = %q, want %q", lead, tt.wantLead)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// extractJSONLD returns every JSON-LD object on the page, flattening top-level arrays and @graph
+func (ac *ArticleCleaner) extractJSONLD(doc *goquery.Document) []map[string]interface{} {
+	var objects []map[string]interface{}
+
+	doc.Find("script[type='application/ld+json']").Each(func(i int, s *goquery.Selection) {
+		raw := strings.TrimSpace(s.Text())
+		if raw == "" {
+			return
+		}
+
+		var data interface{}
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			ac.logger.Debugw("Failed to parse JSON-LD block", "index", i, "error", err)
+			return
+		}
+
+		objects = append(objects, flattenJSONLD(data)...)
+	})
+
+	return objects
+}
+
+// flattenJSONLD expands arrays and @graph containers into a flat list of objects
+func flattenJSONLD(data interface{}) []map[string]interface{} {
+	var objects []map[string]interface{}
+
+	switch value := data.(type) {
+	case []interface{}:
+		for _, item := range value {
+			objects = append(objects, flattenJSONLD(item)...)
+		}
+	case map[string]interface{}:
+		if graph, ok := value["@graph"]; ok {
+			objects = append(objects, flattenJSONLD(graph)...)
+		}
+		objects = append(objects, value)
+	}
+
+	return objects
+}
+
+// jsonLDString returns the string form of a JSON-LD value, taking the first entry of arrays
+// and the @value or name of nested objects
+func jsonLDString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		for _, item := range v {
+			if s := jsonLDString(item); s != "" {
+				return s
+			}
+		}
+	case map[string]interface{}:
+		if s := jsonLDString(v["@value"]); s != "" {
+			return s
+		}
+		return jsonLDString(v["name"])
+	}
+	return ""
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Keyword sources
+const (
+	KeywordSourceText     = "text"
+	KeywordSourceMetadata = "metadata"
+	KeywordSourceBoth     = "both"
+)
+
+// Keyword limits
+const (
+	// keywordMaxWords is the longest key phrase
+	keywordMaxWords = 3
+	// keywordMaxCJKRun is the longest run of Han or Katakana characters taken as one term
+	keywordMaxCJKRun = 6
+	// maxKeywordCount caps the number of keywords returned
+	maxKeywordCount = 50
+)
+
+// Keyword is a ranked key phrase of the article. Score is in 0-1: half comes from the text
+// ranking and half from appearing in the page's tags or keywords metadata.
+type Keyword struct {
+	Term   string  `json:"term"`
+	Score  float64 `json:"score"`
+	Source string  `json:"source"`
+}
+
+// extractKeywordMetadata returns the keywords declared in <meta name="keywords">,
+// <meta name="news_keywords"> and JSON-LD keywords
+func extractKeywordMetadata(doc *goquery.Document, jsonLD []map[string]interface{}) []string {
+	var keywords []string
+	doc.Find("meta[name='keywords'], meta[name='news_keywords']").Each(func(i int, s *goquery.Selection) {
+		keywords = append(keywords, splitKeywordList(s.AttrOr("content", ""))...)
+	})
+
+	for _, obj := range jsonLD {
+		switch value := obj["keywords"].(type) {
+		case string:
+			keywords = append(keywords, splitKeywordList(value)...)
+		case []interface{}:
+			for _, item := range value {
+				keywords = append(keywords, splitKeywordList(jsonLDString(item))...)
+			}
+		}
+	}
+
+	return uniqueKeywords(keywords)
+}
+
+// splitKeywordList splits a comma- or semicolon-separated keyword list
+func splitKeywordList(value string) []string {
+	var keywords []string
+	for _, keyword := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' || r == '、' || r == '，' }) {
+		if keyword = strings.Join(strings.Fields(keyword), " "); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+// uniqueKeywords removes case-insensitive duplicates, keeping the first spelling
+func uniqueKeywords(keywords []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, keyword := range keywords {
+		key := strings.ToLower(keyword)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, keyword)
+	}
+	return unique
+}
+
+// extractKeywords ranks key phrases of the text, delimited RAKE-style by stopwords, and merges them with the metadata
+// keywords, returning at most count keywords
+func extractKeywords(text, language string, metadata, extraStopwords []string, count int) []Keyword {
+	if count <= 0 {
+		return nil
+	}
+	count = min(count, maxKeywordCount)
+
+	extra := make(map[string]bool, len(extraStopwords))
+	for _, word := range extraStopwords {
+		extra[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+
+	ranked := rankKeyPhrases(keyPhrases(text, language, extra))
+	topScore := 0.0
+	if len(ranked) > 0 {
+		topScore = ranked[0].Score
+	}
+
+	keywords := make([]Keyword, 0, len(ranked)+len(metadata))
+	index := make(map[string]int)
+	for _, phrase := range ranked {
+		index[phrase.Term] = len(keywords)
+		keywords = append(keywords, Keyword{
+			Term:   phrase.Term,
+			Score:  roundTo(phrase.Score/topScore/2, 3),
+			Source: KeywordSourceText,
+		})
+	}
+	for _, term := range uniqueKeywords(metadata) {
+		if i, ok := index[strings.ToLower(term)]; ok {
+			keywords[i].Term = term
+			keywords[i].Score = roundTo(keywords[i].Score+0.5, 3)
+			keywords[i].Source = KeywordSourceBoth
+			continue
+		}
+		keywords = append(keywords, Keyword{Term: term, Score: 0.5, Source: KeywordSourceMetadata})
+	}
+
+	sort.SliceStable(keywords, func(a, b int) bool {
+		return keywords[a].Score > keywords[b].Score
+	})
+	if len(keywords) > count {
+		keywords = keywords[:count]
+	}
+	return keywords
+}
+
+// keyPhrases splits the text into runs of content words at punctuation and stopwords. Runs of Han
+// or Katakana characters, which are not separated by spaces, are runs of their own.
+func keyPhrases(text, language string, extraStopwords map[string]bool) [][]string {
+	var phrases [][]string
+	var phrase []string
+	var word strings.Builder
+	var cjkRun strings.Builder
+
+	endPhrase := func() {
+		if len(phrase) > 0 {
+			phrases = append(phrases, phrase)
+		}
+		phrase = nil
+	}
+	endWord := func() {
+		w := strings.Trim(word.String(), "'’-")
+		word.Reset()
+		if w == "" {
+			return
+		}
+		if isStopword(language, w) || extraStopwords[w] || utf8.RuneCountInString(w) < 2 || isNumeric(w) {
+			endPhrase()
+			return
+		}
+		phrase = append(phrase, w)
+	}
+	endCJKRun := func() {
+		run := cjkRun.String()
+		cjkRun.Reset()
+		if n := utf8.RuneCountInString(run); n >= 2 && n <= keywordMaxCJKRun && !extraStopwords[run] {
+			phrases = append(phrases, []string{run})
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Katakana) || r == 'ー':
+			endWord()
+			endPhrase()
+			cjkRun.WriteRune(r)
+		case isCJK(r):
+			// Hiragana is mostly particles and inflections
+			endCJKRun()
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			endCJKRun()
+			word.WriteRune(r)
+		case (r == '\'' || r == '’' || r == '-') && word.Len() > 0:
+			word.WriteRune(r)
+		case unicode.IsSpace(r):
+			endCJKRun()
+			endWord()
+		default:
+			endCJKRun()
+			endWord()
+			endPhrase()
+		}
+	}
+	endCJKRun()
+	endWord()
+	endPhrase()
+
+	return phrases
+}
+
+// rankKeyPhrases scores the word n-grams of the runs by their occurrences times the summed
+// frequency of their words, highest first. Multi-word phrases must occur at least twice, and
+// phrases contained in a higher-ranked phrase are dropped.
+func rankKeyPhrases(phrases [][]string) []Keyword {
+	frequency := make(map[string]int)
+	occurrences := make(map[string]int)
+	words := make(map[string][]string)
+	for _, phrase := range phrases {
+		for i, w := range phrase {
+			frequency[w]++
+			for n := 1; n <= keywordMaxWords && i+n <= len(phrase); n++ {
+				term := strings.Join(phrase[i:i+n], " ")
+				occurrences[term]++
+				words[term] = phrase[i : i+n]
+			}
+		}
+	}
+
+	candidates := make([]Keyword, 0, len(occurrences))
+	for term, count := range occurrences {
+		if len(words[term]) > 1 && count < 2 {
+			continue
+		}
+		score := 0
+		for _, w := range words[term] {
+			score += frequency[w]
+		}
+		candidates = append(candidates, Keyword{Term: term, Score: float64(count * score)})
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].Score != candidates[b].Score {
+			return candidates[a].Score > candidates[b].Score
+		}
+		return candidates[a].Term < candidates[b].Term
+	})
+
+	var ranked []Keyword
+	for _, candidate := range candidates {
+		contained := false
+		for _, selected := range ranked {
+			if strings.Contains(" "+selected.Term+" ", " "+candidate.Term+" ") {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			ranked = append(ranked, candidate)
+		}
+	}
+	return ranked
+}
+
+// isNumeric reports whether a word is made of digits and separators only
+func isNumeric(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) && r != '.' && r != ',' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeyPhrases(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		language string
+		want     [][]string
+	}{
+		{
+			name:     "english",
+			text:     "Solar panels are cheap. The cost of solar panels fell in 2023!",
+			language: "en",
+			want:     [][]string{{"solar", "panels"}, {"cheap"}, {"cost"}, {"solar", "panels", "fell"}},
+		},
+		{
+			name:     "german",
+			text:     "Die Energiewende und die Solarenergie",
+			language: "de",
+			want:     [][]string{{"energiewende"}, {"solarenergie"}},
+		},
+		{
+			name:     "extra stopwords",
+			text:     "Quick brown fox, lazy dog",
+			language: "en",
+			want:     [][]string{{"quick"}, {"fox"}, {"lazy", "dog"}},
+		},
+		{
+			name:     "japanese",
+			text:     "太陽光パネルの価格が下がった。",
+			language: "ja",
+			want:     [][]string{{"太陽光パネル"}, {"価格"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keyPhrases(tt.text, tt.language, map[string]bool{"brown": true}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keyPhrases() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractKeywords(t *testing.T) {
+	text := `Solar panels convert sunlight into electricity. The price of solar panels keeps falling,
+and homeowners install solar panels on their roofs. Electricity bills drop as a result.`
+
+	keywords := extractKeywords(text, "en", []string{"Solar Panels", "Renewable Energy", "solar panels"}, nil, 3)
+	want := []Keyword{
+		{Term: "Solar Panels", Score: 1, Source: KeywordSourceBoth},
+		{Term: "Renewable Energy", Score: 0.5, Source: KeywordSourceMetadata},
+		{Term: "electricity", Score: 0.111, Source: KeywordSourceText},
+	}
+	if !reflect.DeepEqual(keywords, want) {
+		t.Errorf("extractKeywords() = %+v, want %+v", keywords, want)
+	}
+
+	keywords = extractKeywords(text, "en", nil, []string{"Solar"}, 10)
+	for _, keyword := range keywords {
+		if strings.Contains(keyword.Term, "solar") {
+			t.Errorf("Expected extra stopwords to be excluded, got %q", keyword.Term)
+		}
+	}
+
+	if got := extractKeywords(text, "en", nil, nil, 0); got != nil {
+		t.Errorf("Expected no keywords for a count of 0, got %+v", got)
+	}
+}
+
+func TestCleanArticleKeywords(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head>
+	<title>Solar Power</title>
+	<meta property="article:tag" content="Energy">
+	<meta name="keywords" content="solar panels, climate">
+	<script type="application/ld+json">{"@type": "NewsArticle", "keywords": ["Climate", "Rooftops"]}</script>
+</head>
+<body>
+	<article>
+		<p>Solar panels convert sunlight into electricity for homes. The cost of solar panels has
+		dropped sharply this decade. Many homes now install solar panels to cut electricity bills.</p>
+		<p>Solar electricity from panels also reduces emissions. This paragraph exists to give
+		readability enough content to work with.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	options := DefaultArticleOptions()
+	options.KeywordCount = 5
+	article, err := ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+
+	if want := []string{"solar panels", "climate", "Rooftops"}; !reflect.DeepEqual(article.OpenGraph.Keywords, want) {
+		t.Errorf("OpenGraph.Keywords = %q, want %q", article.OpenGraph.Keywords, want)
+	}
+	if len(article.Keywords) != 5 {
+		t.Fatalf("Expected 5 keywords, got %+v", article.Keywords)
+	}
+	if top := article.Keywords[0]; top.Term != "solar panels" || top.Source != KeywordSourceBoth {
+		t.Errorf("Expected solar panels from text and metadata first, got %+v", top)
+	}
+	sources := make(map[string]string)
+	for _, keyword := range article.Keywords {
+		sources[keyword.Term] = keyword.Source
+	}
+	for _, term := range []string{"Energy", "climate", "Rooftops"} {
+		if sources[term] != KeywordSourceMetadata {
+			t.Errorf("Expected metadata keyword %q, got %+v", term, article.Keywords)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// LanguageInfo represents the detected language of an article
+type LanguageInfo struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+	Dir        string  `json:"dir"`
+	Source     string  `json:"source"`
+}
+
+// Confidence assigned to declared language sources
+const (
+	languageConfidenceHTML      = 0.8
+	languageConfidenceHeader    = 0.7
+	languageConfidenceOpenGraph = 0.6
+)
+
+// maxClassifierRunes caps how much text the n-gram classifier looks at
+const maxClassifierRunes = 4000
+
+var (
+	// rtlLanguages are primary language subtags written right-to-left
+	rtlLanguages = map[string]bool{
+		"ar": true, "he": true, "iw": true, "fa": true, "ur": true, "yi": true, "ps": true,
+		"sd": true, "ug": true, "dv": true, "ckb": true,
+	}
+
+	// scriptLanguages maps scripts used by a single language to that language
+	scriptLanguages = []struct {
+		table *unicode.RangeTable
+		lang  string
+	}{
+		{unicode.Hangul, "ko"},
+		{unicode.Thai, "th"},
+		{unicode.Greek, "el"},
+		{unicode.Hebrew, "he"},
+		{unicode.Armenian, "hy"},
+		{unicode.Georgian, "ka"},
+		{unicode.Devanagari, "hi"},
+		{unicode.Bengali, "bn"},
+		{unicode.Tamil, "ta"},
+		{unicode.Telugu, "te"},
+		{unicode.Gujarati, "gu"},
+		{unicode.Kannada, "kn"},
+		{unicode.Malayalam, "ml"},
+		{unicode.Gurmukhi, "pa"},
+		{unicode.Sinhala, "si"},
+		{unicode.Khmer, "km"},
+		{unicode.Lao, "lo"},
+		{unicode.Myanmar, "my"},
+		{unicode.Ethiopic, "am"},
+	}
+
+	// scriptTables maps script names used by languageSamples to their Unicode tables
+	scriptTables = map[string]*unicode.RangeTable{
+		"Latin":    unicode.Latin,
+		"Cyrillic": unicode.Cyrillic,
+		"Arabic":   unicode.Arabic,
+	}
+
+	// languageProfiles holds the trigram frequency profiles built from languageSamples
+	languageProfiles = buildLanguageProfiles()
+)
+
+// trigramProfile is a normalized trigram frequency vector
+type trigramProfile map[string]float64
+
+// detectLanguage combines declared languages with the n-gram classifier run on the cleaned text
+func (ac *ArticleCleaner) detectLanguage(fetched *fetchedDocument, og *OpenGraphData, text string) *LanguageInfo {
+	var declared *LanguageInfo
+
+	html := fetched.doc.Find("html").First()
+	if lang := normalizeLanguageTag(html.AttrOr("lang", html.AttrOr("xml:lang", ""))); lang != "" {
+		declared = &LanguageInfo{Language: lang, Confidence: languageConfidenceHTML, Source: "html_lang"}
+	} else if lang := normalizeLanguageTag(firstHeaderLanguage(fetched.header.Get("Content-Language"))); lang != "" {
+		declared = &LanguageInfo{Language: lang, Confidence: languageConfidenceHeader, Source: "content_language"}
+	} else if og != nil {
+		if lang := normalizeLanguageTag(og.Locale); lang != "" {
+			declared = &LanguageInfo{Language: lang, Confidence: languageConfidenceOpenGraph, Source: "og_locale"}
+		}
+	}
+
+	detected := classifyLanguage(text)
+
+	var result *LanguageInfo
+	switch {
+	case declared == nil && detected == nil:
+		return nil
+	case declared == nil:
+		result = detected
+	case detected == nil:
+		result = declared
+	case primarySubtag(declared.Language) == detected.Language:
+		// Agreement keeps the declared tag (with its region) and raises confidence
+		result = declared
+		result.Confidence = math.Min(1, math.Max(declared.Confidence, detected.Confidence)+0.1)
+		result.Source = declared.Source + "+classifier"
+	case detected.Confidence > declared.Confidence:
+		// Templates often declare a site-wide language that doesn't match the article
+		result = detected
+	default:
+		result = declared
+	}
+
+	result.Confidence = math.Round(result.Confidence*100) / 100
+	result.Dir = languageDirection(result.Language)
+	if dir := strings.ToLower(html.AttrOr("dir", "")); (dir == "rtl" || dir == "ltr") && result.Source != "classifier" {
+		result.Dir = dir
+	}
+
+	ac.logger.Debugw("Detected article language",
+		"language", result.Language,
+		"confidence", result.Confidence,
+		"dir", result.Dir,
+		"source", result.Source,
+	)
+
+	return result
+}
+
+// classifyLanguage detects the language of text by script and trigram profile similarity
+func classifyLanguage(text string) *LanguageInfo {
+	runes := []rune(text)
+	if len(runes) > maxClassifierRunes {
+		runes = runes[:maxClassifierRunes]
+	}
+	text = string(runes)
+
+	letters := 0
+	scriptCounts := make(map[string]int)
+	kana, han := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		}
+		for name, table := range scriptTables {
+			if unicode.Is(table, r) {
+				scriptCounts[name]++
+			}
+		}
+		for _, script := range scriptLanguages {
+			if unicode.Is(script.table, r) {
+				scriptCounts[script.lang]++
+			}
+		}
+	}
+	if letters < 10 {
+		return nil
+	}
+
+	// CJK: kana only appears in Japanese, Han alone means Chinese
+	if kana+han > letters/2 {
+		lang := "zh"
+		if kana > 0 && float64(kana)/float64(kana+han) > 0.1 {
+			lang = "ja"
+		}
+		return &LanguageInfo{Language: lang, Confidence: scriptConfidence(kana+han, letters), Source: "classifier"}
+	}
+
+	// Scripts used by a single language
+	for _, script := range scriptLanguages {
+		if count := scriptCounts[script.lang]; count > letters/2 {
+			return &LanguageInfo{Language: script.lang, Confidence: scriptConfidence(count, letters), Source: "classifier"}
+		}
+	}
+
+	// Scripts shared by several languages: compare trigram profiles
+	for script, profiles := range languageProfiles {
+		count := scriptCounts[script]
+		if count <= letters/2 {
+			continue
+		}
+
+		input := buildTrigramProfile(text)
+		type score struct {
+			lang  string
+			value float64
+		}
+		var scores []score
+		for lang, profile := range profiles {
+			scores = append(scores, score{lang, cosineSimilarity(input, profile)})
+		}
+		sort.Slice(scores, func(i, j int) bool { return scores[i].value > scores[j].value })
+		if len(scores) == 0 || scores[0].value == 0 {
+			return nil
+		}
+
+		// Confidence grows with the margin over the runner-up and with the amount of text
+		margin := 1.0
+		if len(scores) > 1 {
+			margin = (scores[0].value - scores[1].value) / scores[0].value
+		}
+		lengthFactor := math.Min(1, float64(count)/200)
+		confidence := math.Min(0.99, (0.5+margin*2)*lengthFactor*scriptConfidence(count, letters))
+
+		return &LanguageInfo{Language: scores[0].lang, Confidence: confidence, Source: "classifier"}
+	}
+
+	return nil
+}
+
+// scriptConfidence scales confidence by how dominant the detected script is
+func scriptConfidence(count, letters int) float64 {
+	return math.Min(0.99, float64(count)/float64(letters))
+}
+
+// buildLanguageProfiles builds trigram profiles for every language sample
+func buildLanguageProfiles() map[string]map[string]trigramProfile {
+	profiles := make(map[string]map[string]trigramProfile)
+	for script, samples := range languageSamples {
+		profiles[script] = make(map[string]trigramProfile)
+		for lang, sample := range samples {
+			profiles[script][lang] = buildTrigramProfile(sample)
+		}
+	}
+	return profiles
+}
+
+// buildTrigramProfile counts letter trigrams of space-padded, lowercased words
+func buildTrigramProfile(text string) trigramProfile {
+	profile := make(trigramProfile)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '\u200c'
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			profile[string(runes[i:i+3])]++
+		}
+	}
+	return profile
+}
+
+// cosineSimilarity compares two trigram profiles
+func cosineSimilarity(a, b trigramProfile) float64 {
+	var dot, normA, normB float64
+	for gram, countA := range a {
+		dot += countA * b[gram]
+		normA += countA * countA
+	}
+	for _, countB := range b {
+		normB += countB * countB
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// normalizeLanguageTag converts tags like "en_US" or "ZH-hant-tw" to BCP-47 casing
+func normalizeLanguageTag(tag string) string {
+	tag = strings.TrimSpace(strings.ReplaceAll(tag, "_", "-"))
+	if tag == "" || strings.EqualFold(tag, "und") {
+		return ""
+	}
+
+	parts := strings.Split(tag, "-")
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	if len(parts[0]) < 2 || len(parts[0]) > 3 {
+		return ""
+	}
+	return strings.Join(parts, "-")
+}
+
+// firstHeaderLanguage returns the first language listed in a Content-Language header
+func firstHeaderLanguage(header string) string {
+	if i := strings.Index(header, ","); i >= 0 {
+		header = header[:i]
+	}
+	return strings.TrimSpace(header)
+}
+
+// primarySubtag returns the primary language subtag of a BCP-47 tag
+func primarySubtag(tag string) string {
+	if i := strings.Index(tag, "-"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// languageDirection returns "rtl" for right-to-left languages and scripts, "ltr" otherwise
+func languageDirection(tag string) string {
+	if rtlLanguages[primarySubtag(tag)] {
+		return "rtl"
+	}
+	for _, part := range strings.Split(tag, "-")[1:] {
+		switch part {
+		case "Arab", "Hebr", "Syrc", "Thaa", "Nkoo", "Adlm":
+			return "rtl"
+		}
+	}
+	return "ltr"
+}
+
+// wrapMarkdownDirection wraps right-to-left markdown in a dir="rtl" block so renderers keep the
+// paragraph direction instead of laying it out left-to-right
+func wrapMarkdownDirection(markdown, dir string) string {
+	if dir != "rtl" || strings.TrimSpace(markdown) == "" {
+		return markdown
+	}
+	return "<div dir=\"rtl\">\n\n" + strings.TrimSpace(markdown) + "\n\n</div>"
+}
@@ -0,0 +1,106 @@
+package utils
+
+// languageSamples holds short training texts for the built-in trigram classifier, grouped by
+// the script they are written in. Profiles are built from these once at startup.
+var languageSamples = map[string]map[string]string{
+	"Latin": {
+		"en": `All human beings are born free and equal in dignity and rights. They are endowed with reason
+and conscience and should act towards one another in a spirit of brotherhood. The article describes how
+the new system works and why the team decided to build it this year. It is one of the most important
+projects for the company and for the people who use it every day, and we will share more of what we
+have learned when it is ready.`,
+		"es": `Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de
+razón y conciencia, deben comportarse fraternalmente los unos con los otros. El artículo describe cómo
+funciona el nuevo sistema y por qué el equipo decidió construirlo este año. Es uno de los proyectos más
+importantes para la empresa y para las personas que lo usan todos los días, y compartiremos lo que hemos
+aprendido cuando esté listo.`,
+		"fr": `Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison
+et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité. L'article décrit
+comment fonctionne le nouveau système et pourquoi l'équipe a décidé de le construire cette année. C'est
+l'un des projets les plus importants pour l'entreprise et pour les personnes qui l'utilisent chaque jour,
+et nous partagerons ce que nous avons appris quand il sera prêt.`,
+		"de": `Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen
+begabt und sollen einander im Geist der Brüderlichkeit begegnen. Der Artikel beschreibt, wie das neue
+System funktioniert und warum sich das Team entschieden hat, es in diesem Jahr zu bauen. Es ist eines der
+wichtigsten Projekte für das Unternehmen und für die Menschen, die es jeden Tag nutzen, und wir werden
+mehr darüber erzählen, was wir gelernt haben, wenn es fertig ist.`,
+		"it": `Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione
+e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza. L'articolo descrive come
+funziona il nuovo sistema e perché la squadra ha deciso di costruirlo quest'anno. È uno dei progetti più
+importanti per l'azienda e per le persone che lo usano ogni giorno, e condivideremo quello che abbiamo
+imparato quando sarà pronto.`,
+		"pt": `Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de
+consciência, devem agir uns para com os outros em espírito de fraternidade. O artigo descreve como
+funciona o novo sistema e por que a equipe decidiu construí-lo este ano. É um dos projetos mais
+importantes para a empresa e para as pessoas que o usam todos os dias, e vamos compartilhar o que
+aprendemos quando estiver pronto.`,
+		"nl": `Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met
+verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen. Het
+artikel beschrijft hoe het nieuwe systeem werkt en waarom het team heeft besloten het dit jaar te
+bouwen. Het is een van de belangrijkste projecten voor het bedrijf en voor de mensen die het elke dag
+gebruiken, en we zullen delen wat we hebben geleerd wanneer het klaar is.`,
+		"sv": `Alla människor är födda fria och lika i värde och rättigheter. De har utrustats med förnuft och
+samvete och bör handla gentemot varandra i en anda av broderskap. Artikeln beskriver hur det nya systemet
+fungerar och varför teamet bestämde sig för att bygga det i år. Det är ett av de viktigaste projekten för
+företaget och för de människor som använder det varje dag, och vi kommer att berätta mer om vad vi har
+lärt oss när det är klart.`,
+		"da": `Alle mennesker er født frie og lige i værdighed og rettigheder. De er udstyret med fornuft og
+samvittighed, og de bør handle mod hverandre i en broderskabets ånd. Artiklen beskriver, hvordan det nye
+system fungerer, og hvorfor holdet besluttede at bygge det i år. Det er et af de vigtigste projekter for
+virksomheden og for de mennesker, der bruger det hver dag, og vi vil fortælle mere om, hvad vi har lært,
+når det er færdigt.`,
+		"pl": `Wszyscy ludzie rodzą się wolni i równi pod względem swej godności i swych praw. Są oni obdarzeni
+rozumem i sumieniem i powinni postępować wobec innych w duchu braterstwa. Artykuł opisuje, jak działa
+nowy system i dlaczego zespół zdecydował się go zbudować w tym roku. Jest to jeden z najważniejszych
+projektów dla firmy i dla ludzi, którzy korzystają z niego każdego dnia, i podzielimy się tym, czego się
+nauczyliśmy, kiedy będzie gotowy.`,
+		"cs": `Všichni lidé rodí se svobodní a sobě rovní co do důstojnosti a práv. Jsou nadáni rozumem a
+svědomím a mají spolu jednat v duchu bratrství. Článek popisuje, jak nový systém funguje a proč se tým
+rozhodl jej letos postavit. Je to jeden z nejdůležitějších projektů pro firmu a pro lidi, kteří jej
+používají každý den, a až bude hotový, podělíme se o to, co jsme se naučili.`,
+		"tr": `Bütün insanlar hür, haysiyet ve haklar bakımından eşit doğarlar. Akıl ve vicdana sahiptirler ve
+birbirlerine karşı kardeşlik zihniyeti ile hareket etmelidirler. Makale yeni sistemin nasıl çalıştığını
+ve ekibin onu bu yıl neden inşa etmeye karar verdiğini anlatıyor. Bu, şirket ve onu her gün kullanan
+insanlar için en önemli projelerden biridir ve hazır olduğunda öğrendiklerimizi paylaşacağız.`,
+		"id": `Semua orang dilahirkan merdeka dan mempunyai martabat dan hak-hak yang sama. Mereka dikaruniai
+akal dan hati nurani dan hendaknya bergaul satu sama lain dalam semangat persaudaraan. Artikel ini
+menjelaskan bagaimana sistem baru bekerja dan mengapa tim memutuskan untuk membangunnya tahun ini. Ini
+adalah salah satu proyek paling penting bagi perusahaan dan bagi orang-orang yang menggunakannya setiap
+hari, dan kami akan membagikan apa yang telah kami pelajari ketika sudah siap.`,
+		"fi": `Kaikki ihmiset syntyvät vapaina ja tasavertaisina arvoltaan ja oikeuksiltaan. Heille on annettu
+järki ja omatunto, ja heidän on toimittava toisiaan kohtaan veljeyden hengessä. Artikkeli kertoo, miten
+uusi järjestelmä toimii ja miksi tiimi päätti rakentaa sen tänä vuonna. Se on yksi tärkeimmistä
+hankkeista yritykselle ja ihmisille, jotka käyttävät sitä joka päivä, ja kerromme mitä olemme oppineet,
+kun se on valmis.`,
+	},
+	"Cyrillic": {
+		"ru": `Все люди рождаются свободными и равными в своем достоинстве и правах. Они наделены разумом и
+совестью и должны поступать в отношении друг друга в духе братства. В статье рассказывается, как
+работает новая система и почему команда решила построить её в этом году. Это один из самых важных
+проектов для компании и для людей, которые пользуются им каждый день, и мы расскажем, чему мы
+научились, когда он будет готов.`,
+		"uk": `Всі люди народжуються вільними і рівними у своїй гідності та правах. Вони наділені розумом і
+совістю і повинні діяти у відношенні один до одного в дусі братерства. У статті розповідається, як
+працює нова система і чому команда вирішила побудувати її цього року. Це один з найважливіших проєктів
+для компанії та для людей, які користуються ним щодня, і ми розповімо, чого ми навчилися, коли він буде
+готовий.`,
+		"bg": `Всички хора се раждат свободни и равни по достойнство и права. Те са надарени с разум и съвест и
+следва да се отнасят помежду си в дух на братство. Статията описва как работи новата система и защо
+екипът реши да я изгради тази година. Това е един от най-важните проекти за компанията и за хората,
+които го използват всеки ден, и ще споделим какво сме научили, когато е готов.`,
+	},
+	"Arabic": {
+		"ar": `يولد جميع الناس أحرارًا متساوين في الكرامة والحقوق. وقد وهبوا عقلاً وضميرًا وعليهم أن يعامل
+بعضهم بعضًا بروح الإخاء. يصف المقال كيف يعمل النظام الجديد ولماذا قرر الفريق بناءه هذا العام. إنه
+واحد من أهم المشاريع بالنسبة للشركة وللناس الذين يستخدمونه كل يوم، وسنشارك ما تعلمناه عندما يكون
+جاهزًا.`,
+		"fa": `تمام افراد بشر آزاد به دنیا می‌آیند و از لحاظ حیثیت و حقوق با هم برابرند. همه دارای عقل و
+وجدان هستند و باید نسبت به یکدیگر با روح برادری رفتار کنند. این مقاله توضیح می‌دهد که سیستم جدید
+چگونه کار می‌کند و چرا تیم تصمیم گرفت آن را امسال بسازد. این یکی از مهم‌ترین پروژه‌ها برای شرکت و
+برای مردمی است که هر روز از آن استفاده می‌کنند.`,
+		"ur": `تمام انسان آزاد اور حقوق و عزت کے اعتبار سے برابر پیدا ہوئے ہیں۔ انہیں ضمیر اور عقل ودیعت ہوئی
+ہے۔ اس لیے انہیں ایک دوسرے کے ساتھ بھائی چارے کا سلوک کرنا چاہیے۔ یہ مضمون بتاتا ہے کہ نیا نظام کیسے
+کام کرتا ہے اور ٹیم نے اسے اس سال بنانے کا فیصلہ کیوں کیا۔ یہ کمپنی اور ان لوگوں کے لیے سب سے اہم
+منصوبوں میں سے ایک ہے جو اسے ہر روز استعمال کرتے ہیں۔`,
+	},
+}
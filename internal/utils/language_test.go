@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClassifyLanguage(t *testing.T) {
+	tests := []struct {
+		want string
+		text string
+	}{
+		{"en", "The weather was cold this morning, so we stayed inside and read the newspaper while the children played with their friends in the other room."},
+		{"es", "El tiempo estaba frío esta mañana, así que nos quedamos dentro y leímos el periódico mientras los niños jugaban con sus amigos en la otra habitación."},
+		{"fr", "Il faisait froid ce matin, alors nous sommes restés à l'intérieur et avons lu le journal pendant que les enfants jouaient avec leurs amis dans l'autre pièce."},
+		{"de", "Heute Morgen war es kalt, also blieben wir drinnen und lasen die Zeitung, während die Kinder mit ihren Freunden im anderen Zimmer spielten."},
+		{"it", "Questa mattina faceva freddo, quindi siamo rimasti dentro e abbiamo letto il giornale mentre i bambini giocavano con i loro amici nell'altra stanza."},
+		{"pt", "Esta manhã estava frio, então ficamos dentro de casa e lemos o jornal enquanto as crianças brincavam com os seus amigos no outro quarto."},
+		{"nl", "Vanochtend was het koud, dus bleven we binnen en lazen we de krant terwijl de kinderen met hun vrienden in de andere kamer speelden."},
+		{"ru", "Сегодня утром было холодно, поэтому мы остались дома и читали газету, пока дети играли со своими друзьями в другой комнате."},
+		{"ar", "كان الطقس باردا هذا الصباح، لذلك بقينا في المنزل وقرأنا الصحيفة بينما كان الأطفال يلعبون مع أصدقائهم في الغرفة الأخرى."},
+		{"he", "הבוקר היה קר, אז נשארנו בבית וקראנו את העיתון בזמן שהילדים שיחקו עם החברים שלהם בחדר השני."},
+		{"ja", "今朝は寒かったので、私たちは家の中にいて、子供たちが友達と別の部屋で遊んでいる間に新聞を読みました。"},
+		{"zh", "今天早上很冷，所以我们待在家里看报纸，孩子们和他们的朋友在另一个房间里玩。"},
+		{"hi", "आज सुबह ठंड थी, इसलिए हम घर के अंदर रहे और अखबार पढ़ा जबकि बच्चे दूसरे कमरे में अपने दोस्तों के साथ खेल रहे थे।"},
+		{"ko", "오늘 아침은 추워서 우리는 집 안에 머물며 신문을 읽었고 아이들은 다른 방에서 친구들과 놀았습니다."},
+	}
+
+	for _, tt := range tests {
+		got := classifyLanguage(tt.text)
+		if got == nil {
+			t.Errorf("%s: no language detected", tt.want)
+			continue
+		}
+		if got.Language != tt.want {
+			t.Errorf("%s: got %s (confidence %.2f)", tt.want, got.Language, got.Confidence)
+		}
+	}
+
+	if got := classifyLanguage("123 456"); got != nil {
+		t.Errorf("Expected no language for text without letters, got %+v", got)
+	}
+}
+
+func TestNormalizeLanguageTag(t *testing.T) {
+	tests := map[string]string{
+		"en_US":      "en-US",
+		"EN-gb":      "en-GB",
+		"zh-hant-tw": "zh-Hant-TW",
+		"und":        "",
+		"":           "",
+	}
+	for input, want := range tests {
+		if got := normalizeLanguageTag(input); got != want {
+			t.Errorf("%q: got %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCleanArticleDetectsRTLLanguage(t *testing.T) {
+	html := `<html lang="ar">
+<head><title>مقال</title></head>
+<body>
+	<article>
+		<h1>مقال تجريبي</h1>
+		<p>يولد جميع الناس أحرارًا متساوين في الكرامة والحقوق. وقد وهبوا عقلاً وضميرًا وعليهم أن يعامل بعضهم بعضًا بروح الإخاء.
+		كان الطقس باردا هذا الصباح، لذلك بقينا في المنزل وقرأنا الصحيفة بينما كان الأطفال يلعبون مع أصدقائهم في الغرفة الأخرى.</p>
+		<p>يصف المقال كيف يعمل النظام الجديد ولماذا قرر الفريق بناءه هذا العام. إنه واحد من أهم المشاريع بالنسبة للشركة وللناس الذين يستخدمونه كل يوم.</p>
+	</article>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(html))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	if article.Language != "ar" {
+		t.Errorf("Expected language 'ar', got %q", article.Language)
+	}
+	if article.Dir != "rtl" {
+		t.Errorf("Expected dir 'rtl', got %q", article.Dir)
+	}
+	if article.LanguageConfidence <= languageConfidenceHTML {
+		t.Errorf("Expected agreeing sources to raise confidence, got %v", article.LanguageConfidence)
+	}
+	if !strings.HasPrefix(article.Markdown, `<div dir="rtl">`) {
+		t.Errorf("Expected RTL markdown wrapper, got %q", article.Markdown)
+	}
+}
+
+func TestCleanArticleOverridesTemplateLanguage(t *testing.T) {
+	html := `<html lang="en">
+<head><title>Artikel</title></head>
+<body>
+	<article>
+		<p>Heute Morgen war es kalt, also blieben wir drinnen und lasen die Zeitung, während die Kinder mit ihren
+		Freunden im anderen Zimmer spielten. Der Artikel beschreibt, wie das neue System funktioniert.</p>
+		<p>Es ist eines der wichtigsten Projekte für das Unternehmen und für die Menschen, die es jeden Tag nutzen,
+		und wir werden mehr darüber erzählen, wenn es fertig ist.</p>
+	</article>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(html))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+	if article.Language != "de" || article.Dir != "ltr" {
+		t.Errorf("Expected de/ltr, got %q/%q (confidence %v)", article.Language, article.Dir, article.LanguageConfidence)
+	}
+}
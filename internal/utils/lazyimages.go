@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"encoding/base64"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	// lazySrcAttributes hold the real image URL on lazy-loading sites, in order of preference
+	lazySrcAttributes = []string{
+		"data-src", "data-lazy-src", "data-original", "data-lazy", "data-url", "data-hi-res-src",
+	}
+
+	// lazySrcsetAttributes hold the real srcset on lazy-loading sites
+	lazySrcsetAttributes = []string{"data-srcset", "data-lazy-srcset"}
+
+	// lazyImageClasses mark images whose source a lazy-loading script swaps in
+	lazyImageClasses = []string{"lazy", "lazyload", "lazy-load", "lazyloading", "b-lazy"}
+
+	// blankImagePayloadPrefixes start the base64 payloads of 1×1 GIF and PNG spacers
+	blankImagePayloadPrefixes = []string{"R0lGODlhAQABA", "R0lGODdhAQABA", "iVBORw0KGgoAAAANSUhEUgAAAAEAAAAB"}
+
+	// svgDrawingPattern matches SVG elements that draw something; inline SVGs without them are blank
+	svgDrawingPattern = regexp.MustCompile(`(?i)<(?:path|rect|circle|ellipse|line|polyline|polygon|text|image|use)\b`)
+
+	// placeholderImageNames are file names commonly used for spacer and tracking images
+	placeholderImageNames = map[string]bool{
+		"spacer.gif": true, "blank.gif": true, "transparent.gif": true, "pixel.gif": true,
+		"1x1.gif": true, "1x1.png": true, "clear.gif": true, "placeholder.gif": true,
+	}
+)
+
+const (
+	// maxPlaceholderPixels is the largest declared width or height treated as a tracking pixel
+	maxPlaceholderPixels = 2
+	// maxPlaceholderDataURILength is the longest inline data URI on a lazy-loading image treated as
+	// a blur or spacer stub
+	maxPlaceholderDataURILength = 4096
+)
+
+// recoverNoscriptImages restores real images from <noscript> fallbacks before noscript is removed
+func (ac *ArticleCleaner) recoverNoscriptImages(doc *goquery.Document) {
+	recoveredCount := 0
+	doc.Find("noscript").Each(func(i int, noscript *goquery.Selection) {
+		// noscript content is parsed as raw text while scripting is enabled
+		fragment, err := goquery.NewDocumentFromReader(strings.NewReader(noscript.Text()))
+		if err != nil {
+			return
+		}
+		images := fragment.Find("img[src]")
+		if images.Length() == 0 {
+			return
+		}
+
+		images.Each(func(j int, img *goquery.Selection) {
+			src := strings.TrimSpace(img.AttrOr("src", ""))
+			if src == "" || isPlaceholderSrc(src) {
+				return
+			}
+			imgHTML, err := goquery.OuterHtml(img)
+			if err != nil {
+				return
+			}
+
+			// Replace the lazy placeholder the noscript accompanies, if there is one
+			if placeholder := noscript.PrevAllFiltered("img").First(); placeholder.Length() > 0 && isLazyPlaceholder(placeholder) {
+				placeholder.ReplaceWithHtml(imgHTML)
+				recoveredCount++
+				return
+			}
+			duplicate := noscript.Parent().Find("img").FilterFunction(func(k int, existing *goquery.Selection) bool {
+				return existing.AttrOr("src", "") == src
+			})
+			if duplicate.Length() > 0 {
+				return
+			}
+			noscript.BeforeHtml(imgHTML)
+			recoveredCount++
+		})
+	})
+
+	if recoveredCount > 0 {
+		ac.logger.Debugw("Recovered images from noscript fallbacks", "count", recoveredCount)
+	}
+}
+
+// promoteLazyImages moves lazy-loading attributes into src and srcset
+func (ac *ArticleCleaner) promoteLazyImages(doc *goquery.Document) {
+	promotedCount := 0
+	doc.Find("img, source").Each(func(i int, s *goquery.Selection) {
+		promoted := false
+
+		if goquery.NodeName(s) == "img" {
+			for _, attr := range lazySrcAttributes {
+				if value := strings.TrimSpace(s.AttrOr(attr, "")); value != "" && !isPlaceholderSrc(value) {
+					s.SetAttr("src", value)
+					s.RemoveAttr(attr)
+					promoted = true
+					break
+				}
+			}
+		}
+
+		for _, attr := range lazySrcsetAttributes {
+			if value := strings.TrimSpace(s.AttrOr(attr, "")); value != "" {
+				s.SetAttr("srcset", value)
+				s.RemoveAttr(attr)
+				promoted = true
+				break
+			}
+		}
+
+		if promoted {
+			promotedCount++
+		}
+	})
+
+	if promotedCount > 0 {
+		ac.logger.Debugw("Promoted lazy-loaded image attributes", "count", promotedCount)
+	}
+}
+
+// removePlaceholderImages drops tracking pixels and placeholders that have no real source
+func (ac *ArticleCleaner) removePlaceholderImages(doc *goquery.Document) {
+	removedCount := 0
+	doc.Find("img").Each(func(i int, img *goquery.Selection) {
+		if isTrackingPixel(img) || isPlaceholderImage(img) {
+			img.Remove()
+			removedCount++
+		}
+	})
+
+	if removedCount > 0 {
+		ac.logger.Debugw("Removed placeholder images", "count", removedCount)
+	}
+}
+
+// isLazyPlaceholder reports whether an img is a lazy-loading stub awaiting its real source
+func isLazyPlaceholder(img *goquery.Selection) bool {
+	return isPlaceholderSrc(strings.TrimSpace(img.AttrOr("src", ""))) || isMarkedLazy(img)
+}
+
+// isPlaceholderImage reports whether an img shows no real image: its source is missing or blank,
+// or it is a small inline stub on an image marked for lazy loading. Small inline images without
+// lazy-loading markers, such as icons, are real.
+func isPlaceholderImage(img *goquery.Selection) bool {
+	src := strings.TrimSpace(img.AttrOr("src", ""))
+	if isPlaceholderSrc(src) {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(src), "data:") && len(src) <= maxPlaceholderDataURILength && isMarkedLazy(img)
+}
+
+// isMarkedLazy reports whether an img carries lazy-loading attributes or classes
+func isMarkedLazy(img *goquery.Selection) bool {
+	for _, class := range lazyImageClasses {
+		if img.HasClass(class) {
+			return true
+		}
+	}
+	for _, attr := range lazySrcAttributes {
+		if _, exists := img.Attr(attr); exists {
+			return true
+		}
+	}
+	for _, attr := range lazySrcsetAttributes {
+		if _, exists := img.Attr(attr); exists {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlaceholderSrc reports whether src is missing, a blank inline image or a known spacer image
+func isPlaceholderSrc(src string) bool {
+	if src == "" {
+		return true
+	}
+	if strings.HasPrefix(strings.ToLower(src), "data:") {
+		return isBlankDataURI(src)
+	}
+	name := strings.ToLower(path.Base(strings.SplitN(strings.SplitN(src, "?", 2)[0], "#", 2)[0]))
+	return placeholderImageNames[name]
+}
+
+// isBlankDataURI reports whether a data URI holds nothing, a 1×1 GIF or PNG, or an SVG that
+// draws nothing
+func isBlankDataURI(src string) bool {
+	header, payload, _ := strings.Cut(src, ",")
+	payload = strings.TrimSpace(payload)
+	if payload == "" {
+		return true
+	}
+	header = strings.ToLower(header)
+	if strings.HasPrefix(header, "data:image/svg+xml") {
+		var svg []byte
+		var err error
+		if strings.HasSuffix(header, ";base64") {
+			svg, err = base64.StdEncoding.DecodeString(payload)
+		} else {
+			var unescaped string
+			unescaped, err = url.PathUnescape(payload)
+			svg = []byte(unescaped)
+		}
+		return err == nil && !svgDrawingPattern.Match(svg)
+	}
+	for _, prefix := range blankImagePayloadPrefixes {
+		if strings.HasPrefix(payload, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrackingPixel reports whether an img declares tracking-pixel dimensions
+func isTrackingPixel(img *goquery.Selection) bool {
+	for _, attr := range []string{"width", "height"} {
+		value := strings.TrimSuffix(strings.TrimSpace(img.AttrOr(attr, "")), "px")
+		if n, err := strconv.Atoi(value); err == nil && n <= maxPlaceholderPixels {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestCleanArticleWithLazyLoadedImages(t *testing.T) {
+	// Simulate a lazy-loading blog with placeholders, noscript fallbacks and a tracking pixel
+	lazyHTML := `<html>
+<head><title>Lazy Images</title></head>
+<body>
+	<article>
+		<h1>Lazy Images</h1>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<figure>
+			<img src="data:image/gif;base64,R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7" data-src="/images/data-src.jpg" alt="Data src">
+		</figure>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+		<figure>
+			<img src="/img/blank.gif" data-lazy-src="/images/lazy-src.jpg" alt="Lazy src">
+		</figure>
+		<figure>
+			<img class="lazyload" data-original="/images/original.jpg" alt="Original">
+		</figure>
+		<figure>
+			<img class="lazyload" src="data:image/svg+xml,%3Csvg%3E%3C/svg%3E" data-srcset="/images/small.jpg 400w, /images/large.jpg 1200w" alt="Srcset">
+		</figure>
+		<p>And a third paragraph discussing the architecture decisions that were made during
+		the design phase of this particular system component.</p>
+		<figure>
+			<img class="lazy" src="data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD" alt="Blur">
+			<noscript><img src="/images/noscript.jpg" alt="Noscript"></noscript>
+		</figure>
+		<img src="https://tracker.example.com/collect?id=1" width="1" height="1" alt="">
+	</article>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(lazyHTML))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	for _, want := range []string{
+		ts.URL + "/images/data-src.jpg",
+		ts.URL + "/images/lazy-src.jpg",
+		ts.URL + "/images/original.jpg",
+		ts.URL + "/images/large.jpg",
+		ts.URL + "/images/noscript.jpg",
+	} {
+		if !strings.Contains(article.Markdown, want) {
+			t.Errorf("Expected markdown to contain %s", want)
+		}
+	}
+
+	for _, unwanted := range []string{"data:image", "blank.gif", "tracker.example.com"} {
+		if strings.Contains(article.Markdown, unwanted) {
+			t.Errorf("Expected markdown not to contain %s", unwanted)
+		}
+	}
+}
+
+func TestProcessImagesKeepsInlineImages(t *testing.T) {
+	icon := "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAABAAAAAQCAYAAAAf8/9hAAAAFklEQVR42mNk+M9Qz0AEYBxVSF+FAP5FDvcfRYWgAAAAAElFTkSuQmCC"
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<body>
+		<p><img src="` + icon + `" alt="icon"> Settings</p>
+		<img src="data:image/gif;base64,R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7" alt="spacer">
+		<img src="data:image/svg+xml,%3Csvg xmlns='http://www.w3.org/2000/svg'%3E%3C/svg%3E" alt="blank svg">
+		<img class="lazyload" src="data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD" alt="blur">
+	</body>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	ac.processImages(doc, nil, defaultImageFormats)
+
+	var alts []string
+	doc.Find("img").Each(func(i int, img *goquery.Selection) {
+		alts = append(alts, img.AttrOr("alt", ""))
+	})
+	if len(alts) != 1 || alts[0] != "icon" {
+		t.Errorf("Expected only the inline icon to be kept, got %q", alts)
+	}
+	if src := doc.Find("img").AttrOr("src", ""); src != icon {
+		t.Errorf("Expected the icon source to be unchanged, got %q", src)
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ArticleLink describes a hyperlink in the cleaned article
+type ArticleLink struct {
+	URL      string   `json:"url"`
+	Text     string   `json:"text,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Rel      []string `json:"rel,omitempty"`
+	Internal bool     `json:"internal"`
+}
+
+// collectArticleLinks lists the http(s) links of the cleaned article content in document order,
+// once per URL, with tracking parameters removed
+func (ac *ArticleCleaner) collectArticleLinks(content string, pageURL *url.URL, trackingParams []string) []ArticleLink {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		ac.logger.Warnw("Failed to parse article content for links", "error", err)
+		return nil
+	}
+
+	var links []ArticleLink
+	seen := make(map[string]bool)
+	doc.Find("a[href]").Each(func(i int, a *goquery.Selection) {
+		href := stripTrackingParams(strings.TrimSpace(a.AttrOr("href", "")), trackingParams)
+		parsed, err := url.Parse(href)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || seen[href] {
+			return
+		}
+		seen[href] = true
+
+		link := ArticleLink{
+			URL:      href,
+			Text:     strings.Join(strings.Fields(a.Text()), " "),
+			Title:    strings.TrimSpace(a.AttrOr("title", "")),
+			Internal: sameSite(parsed, pageURL),
+		}
+		if rel := strings.Fields(strings.ToLower(a.AttrOr("rel", ""))); len(rel) > 0 {
+			link.Rel = rel
+		}
+		links = append(links, link)
+	})
+
+	if len(links) > 0 {
+		ac.logger.Debugw("Collected article links", "count", len(links))
+	}
+
+	return links
+}
+
+// rewriteTrackingLinks removes tracking parameters from every link in the document
+func (ac *ArticleCleaner) rewriteTrackingLinks(doc *goquery.Document, trackingParams []string) {
+	rewrittenCount := 0
+	doc.Find("a[href]").Each(func(i int, a *goquery.Selection) {
+		href := a.AttrOr("href", "")
+		if cleaned := stripTrackingParams(href, trackingParams); cleaned != href {
+			a.SetAttr("href", cleaned)
+			rewrittenCount++
+		}
+	})
+
+	if rewrittenCount > 0 {
+		ac.logger.Debugw("Removed tracking parameters from links", "count", rewrittenCount)
+	}
+}
+
+// sameSite reports whether a link points at the same host as the page, ignoring a www. prefix
+func sameSite(link, pageURL *url.URL) bool {
+	if pageURL == nil {
+		return false
+	}
+	return strings.EqualFold(
+		strings.TrimPrefix(strings.ToLower(link.Hostname()), "www."),
+		strings.TrimPrefix(strings.ToLower(pageURL.Hostname()), "www."),
+	)
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStripTrackingParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		url   string
+		rules []string
+		want  string
+	}{
+		{"utm wildcard", "https://example.com/a?utm_source=x&utm_medium=y&id=7", defaultTrackingParams, "https://example.com/a?id=7"},
+		{"order of remaining params kept", "https://example.com/a?z=1&fbclid=abc&a=2", defaultTrackingParams, "https://example.com/a?z=1&a=2"},
+		{"all params removed", "https://example.com/a?gclid=1&mc_eid=2#top", defaultTrackingParams, "https://example.com/a#top"},
+		{"case insensitive", "https://example.com/a?UTM_Campaign=x", defaultTrackingParams, "https://example.com/a"},
+		{"encoded key", "https://example.com/a?utm%5Fsource=x&q=go", defaultTrackingParams, "https://example.com/a?q=go"},
+		{"no tracking params untouched", "https://example.com/a?b=2&a=1", defaultTrackingParams, "https://example.com/a?b=2&a=1"},
+		{"custom rule", "https://example.com/a?ref=home&src_campaign=x&q=1", []string{"ref", "src_*"}, "https://example.com/a?q=1"},
+		{"no rules", "https://example.com/a?utm_source=x", nil, "https://example.com/a?utm_source=x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripTrackingParams(tt.url, tt.rules); got != tt.want {
+				t.Errorf("stripTrackingParams(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanArticleLinks(t *testing.T) {
+	var serverURL string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>Links</title></head>
+<body>
+	<nav><a href="/home">Home</a></nav>
+	<article>
+		<h1>Links</h1>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the <a href="/docs/readability?utm_source=blog&amp;v=2" title="Docs">content
+		length threshold</a> that readability uses to determine if something is actual article content or just noise.</p>
+		<p>Here is a second paragraph with more meaningful content about <a href="https://other.example.org/systems?fbclid=XYZ" rel="nofollow noopener">distributed systems</a>
+		and how they handle failure modes in production environments, as <a href="` + serverURL + `/docs/readability?v=2">the docs</a> explain.</p>
+		<p>And a third paragraph discussing the architecture decisions that were made during
+		the design phase of this particular <a href="mailto:team@example.com">system component</a>.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+	serverURL = ts.URL
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	want := []ArticleLink{
+		{URL: ts.URL + "/docs/readability?v=2", Text: "content length threshold", Title: "Docs", Internal: true},
+		{URL: "https://other.example.org/systems", Text: "distributed systems", Rel: []string{"nofollow", "noopener"}},
+	}
+	if !reflect.DeepEqual(article.Links, want) {
+		t.Errorf("Links = %+v, want %+v", article.Links, want)
+	}
+	if !strings.Contains(article.Markdown, "utm_source=blog") {
+		t.Error("Expected markdown links to be left as published by default")
+	}
+
+	options := DefaultArticleOptions()
+	options.RewriteLinks = true
+	article, err = ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+	for _, unwanted := range []string{"utm_source", "fbclid"} {
+		if strings.Contains(article.Markdown, unwanted) {
+			t.Errorf("Expected rewritten markdown not to contain %s", unwanted)
+		}
+	}
+}
@@ -0,0 +1,496 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/JohannesKaufmann/html-to-markdown/plugin"
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Markdown flavors
+const (
+	// MarkdownFlavorGFM renders tables, strikethrough and task lists as GitHub Flavored Markdown
+	MarkdownFlavorGFM = "gfm"
+	// MarkdownFlavorCommonMark sticks to CommonMark, embedding tables and strikethrough as HTML
+	MarkdownFlavorCommonMark = "commonmark"
+)
+
+// Markdown heading and link styles
+const (
+	// MarkdownHeadingATX writes headings as "# Title"
+	MarkdownHeadingATX = "atx"
+	// MarkdownHeadingSetext underlines level 1 and 2 headings; deeper levels stay ATX
+	MarkdownHeadingSetext = "setext"
+	// MarkdownLinkInlined writes links as [text](url)
+	MarkdownLinkInlined = "inlined"
+	// MarkdownLinkReferenced writes links as [text][1] and lists the URLs at the end
+	MarkdownLinkReferenced = "referenced"
+)
+
+// MarkdownOptions controls how the article HTML is rendered as markdown. Converters are cached
+// per option set, so the struct only holds comparable values.
+type MarkdownOptions struct {
+	// Flavor is MarkdownFlavorGFM (default) or MarkdownFlavorCommonMark
+	Flavor string
+	// HeadingStyle is MarkdownHeadingATX (default) or MarkdownHeadingSetext
+	HeadingStyle string
+	// BulletListMarker is "-" (default), "*" or "+"
+	BulletListMarker string
+	// LinkStyle is MarkdownLinkInlined (default) or MarkdownLinkReferenced
+	LinkStyle string
+	// DropImages leaves images and embed thumbnails out of the markdown
+	DropImages bool
+	// MaxHeadingLevel renders deeper headings at this level; 0 keeps all six levels
+	MaxHeadingLevel int
+	// WrapWidth hard-wraps paragraphs and list items at this many characters; 0 disables wrapping
+	WrapWidth int
+}
+
+// DefaultMarkdownOptions returns the markdown options used by CleanArticle
+func DefaultMarkdownOptions() MarkdownOptions {
+	return MarkdownOptions{
+		Flavor:           MarkdownFlavorGFM,
+		HeadingStyle:     MarkdownHeadingATX,
+		BulletListMarker: "-",
+		LinkStyle:        MarkdownLinkInlined,
+	}
+}
+
+// Validate reports the first option the converter does not support; empty values use the default
+func (o MarkdownOptions) Validate() error {
+	switch {
+	case o.Flavor != "" && o.Flavor != MarkdownFlavorGFM && o.Flavor != MarkdownFlavorCommonMark:
+		return fmt.Errorf("unsupported markdown flavor %q", o.Flavor)
+	case o.HeadingStyle != "" && o.HeadingStyle != MarkdownHeadingATX && o.HeadingStyle != MarkdownHeadingSetext:
+		return fmt.Errorf("unsupported heading style %q", o.HeadingStyle)
+	case o.BulletListMarker != "" && o.BulletListMarker != "-" && o.BulletListMarker != "*" && o.BulletListMarker != "+":
+		return fmt.Errorf("unsupported bullet list marker %q", o.BulletListMarker)
+	case o.LinkStyle != "" && o.LinkStyle != MarkdownLinkInlined && o.LinkStyle != MarkdownLinkReferenced:
+		return fmt.Errorf("unsupported link style %q", o.LinkStyle)
+	case o.MaxHeadingLevel < 0 || o.MaxHeadingLevel > 6:
+		return fmt.Errorf("max heading level must be between 1 and 6")
+	case o.WrapWidth < 0:
+		return fmt.Errorf("wrap width must not be negative")
+	}
+	return nil
+}
+
+// markdownConverters caches a converter per MarkdownOptions; converters are safe for concurrent use
+var markdownConverters sync.Map
+
+// dollarPlaceholder stands in for literal dollar signs during conversion. The converter escapes
+// backslashes already in the text, so the \$ escape is written after conversion.
+const dollarPlaceholder = "\uE000"
+
+// tableBlockSelectors match cell content that a pipe table cannot hold on one line
+var tableBlockSelectors = []string{"table", "ul", "ol", "pre", "blockquote", "h1", "h2", "h3", "h4", "h5", "h6", "hr", "p + p"}
+
+// textAlignPattern reads the text-align declaration of an inline style
+var textAlignPattern = regexp.MustCompile(`(?i)text-align\s*:\s*(left|center|right)`)
+
+// blankLinePattern matches whitespace-only lines, which would end an HTML block in markdown
+var blankLinePattern = regexp.MustCompile(`\n(?:[ \t]*\n)+`)
+
+// preserveMarkdownHints records table cell alignment and task list checkboxes in data attributes
+// and marker elements, since readability strips align and style attributes and removes inputs
+func (ac *ArticleCleaner) preserveMarkdownHints(doc *goquery.Document) {
+	doc.Find("th, td").Each(func(i int, cell *goquery.Selection) {
+		align := strings.ToLower(strings.TrimSpace(cell.AttrOr("align", "")))
+		if match := textAlignPattern.FindStringSubmatch(cell.AttrOr("style", "")); match != nil {
+			align = strings.ToLower(match[1])
+		}
+		if align == "left" || align == "center" || align == "right" {
+			cell.SetAttr("data-align", align)
+		}
+	})
+
+	doc.Find(`input[type="checkbox"]`).Each(func(i int, input *goquery.Selection) {
+		if input.Closest("li").Length() == 0 {
+			return
+		}
+		state := "open"
+		if _, checked := input.Attr("checked"); checked {
+			state = "checked"
+		}
+		input.ReplaceWithHtml(`<span data-task="` + state + `"></span>`)
+	})
+}
+
+// restoreMarkdownHints turns the hints left by preserveMarkdownHints back into the align
+// attributes and checkboxes the markdown converter understands
+func restoreMarkdownHints(selec *goquery.Selection) {
+	selec.Find("th[data-align], td[data-align]").Each(func(i int, cell *goquery.Selection) {
+		cell.SetAttr("align", cell.AttrOr("data-align", ""))
+		cell.RemoveAttr("data-align")
+	})
+
+	selec.Find("span[data-task]").Each(func(i int, marker *goquery.Selection) {
+		checked := ""
+		if marker.AttrOr("data-task", "") == "checked" {
+			checked = " checked"
+		}
+		// The converter writes its own space after the checkbox
+		if next := marker.Get(0).NextSibling; next != nil && next.Type == html.TextNode {
+			next.Data = strings.TrimLeft(next.Data, " \t\n")
+		}
+		// Task list checkboxes must be direct children of the list item
+		if item := marker.Closest("li"); item.Length() > 0 && marker.Parent().Get(0) != item.Get(0) {
+			marker.Remove()
+			item.PrependHtml(`<input type="checkbox"` + checked + `>`)
+			return
+		}
+		marker.ReplaceWithHtml(`<input type="checkbox"` + checked + `>`)
+	})
+}
+
+// markdownConverter returns the cached converter for the options, building it on first use.
+// Invalid options fall back to the defaults.
+func (ac *ArticleCleaner) markdownConverter(options MarkdownOptions) *md.Converter {
+	if err := options.Validate(); err != nil {
+		ac.logger.Warnw("Invalid markdown options, using defaults", "error", err)
+		options = DefaultMarkdownOptions()
+	}
+	// Wrapping is applied to the converted markdown and needs no converter of its own
+	options.WrapWidth = 0
+
+	if converter, ok := markdownConverters.Load(options); ok {
+		return converter.(*md.Converter)
+	}
+	converter, _ := markdownConverters.LoadOrStore(options, newMarkdownConverter(options))
+	return converter.(*md.Converter)
+}
+
+// newMarkdownConverter returns an HTML to markdown converter for the given options
+func newMarkdownConverter(options MarkdownOptions) *md.Converter {
+	converter := md.NewConverter("", true, &md.Options{
+		HeadingStyle:     options.HeadingStyle,
+		BulletListMarker: options.BulletListMarker,
+		LinkStyle:        options.LinkStyle,
+	})
+	converter.Before(restoreMarkdownHints, escapeDollarSigns)
+	converter.After(func(markdown string) string {
+		return strings.ReplaceAll(markdown, dollarPlaceholder, `\$`)
+	})
+	if options.MaxHeadingLevel > 0 {
+		converter.Before(func(selec *goquery.Selection) {
+			clampHeadingLevels(selec, options.MaxHeadingLevel)
+		})
+	}
+	converter.AddRules(md.Rule{
+		Filter: []string{"sup"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			label, ok := selec.Attr("data-footnote")
+			if !ok {
+				return nil
+			}
+			reference := "[^" + label + "]"
+			return &reference
+		},
+	}, md.Rule{
+		Filter: []string{"p"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			embed, ok := embedMarkdown(selec, !options.DropImages)
+			if !ok {
+				return nil
+			}
+			return &embed
+		},
+	}, md.Rule{
+		Filter: []string{"span"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			math, ok := mathMarkdown(selec)
+			if !ok {
+				return nil
+			}
+			return &math
+		},
+	})
+
+	if options.DropImages {
+		converter.Before(removeImages)
+	}
+	if options.LinkStyle == MarkdownLinkReferenced {
+		converter.After(dedupeLinkReferences)
+	}
+
+	if options.Flavor == MarkdownFlavorCommonMark {
+		// CommonMark has no tables or strikethrough, but allows raw HTML
+		converter.Use(plugin.TaskListItems())
+		converter.Keep("del", "s", "strike")
+		converter.AddRules(md.Rule{
+			Filter: []string{"table"},
+			Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+				return embeddedTableHTML(selec)
+			},
+		})
+		return converter
+	}
+
+	converter.Use(plugin.GitHubFlavored())
+	// Rules added later take precedence; returning nil falls back to the pipe table
+	converter.AddRules(md.Rule{
+		Filter: []string{"table"},
+		Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+			if !tableNeedsHTML(selec) {
+				return nil
+			}
+			return embeddedTableHTML(selec)
+		},
+	})
+	return converter
+}
+
+// tableNeedsHTML reports whether a table cannot be written as a pipe table: cells spanning
+// several rows or columns, nested tables and block content all need the HTML fallback
+func tableNeedsHTML(table *goquery.Selection) bool {
+	needsHTML := false
+	table.Find("th, td").EachWithBreak(func(i int, cell *goquery.Selection) bool {
+		for _, attr := range []string{"colspan", "rowspan"} {
+			if span, err := strconv.Atoi(strings.TrimSpace(cell.AttrOr(attr, "1"))); err == nil && span > 1 {
+				needsHTML = true
+				return false
+			}
+		}
+		if cell.Find(strings.Join(tableBlockSelectors, ", ")).Length() > 0 {
+			needsHTML = true
+			return false
+		}
+		return true
+	})
+	return needsHTML
+}
+
+// embeddedTableHTML renders a table as an HTML block surrounded by blank lines
+func embeddedTableHTML(table *goquery.Selection) *string {
+	// The converter annotates list items while converting; those attributes are not content
+	table = table.Clone()
+	table.Find("[data-converter-list-prefix]").RemoveAttr("data-converter-list-prefix")
+	outer, err := goquery.OuterHtml(table)
+	if err != nil {
+		return nil
+	}
+	// Markdown is not parsed inside HTML blocks, so dollar signs stay as they are
+	outer = strings.ReplaceAll(outer, dollarPlaceholder, "$")
+	outer = "\n\n" + strings.TrimSpace(blankLinePattern.ReplaceAllString(outer, "\n")) + "\n\n"
+	return &outer
+}
+
+// linkReferencePattern matches the link reference definitions the converter lists at the end
+var linkReferencePattern = regexp.MustCompile(`^\[\d+\]: \S`)
+
+// dedupeLinkReferences drops repeated link reference definitions. The converter lists the
+// references of an element again for every rule that falls through, as ours for p and span do.
+func dedupeLinkReferences(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	seen := make(map[string]bool)
+	result := lines[:0]
+	for _, line := range lines {
+		if linkReferencePattern.MatchString(line) {
+			if seen[line] {
+				continue
+			}
+			seen[line] = true
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+// escapeDollarSigns marks the literal dollar signs of text outside code and math, which renderers
+// with math support would otherwise read as formula delimiters now that math is written as $...$
+func escapeDollarSigns(selec *goquery.Selection) {
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.TextNode:
+				child.Data = strings.ReplaceAll(child.Data, "$", dollarPlaceholder)
+			case child.Type == html.ElementNode && (child.Data == "pre" || child.Data == "code" || isMathNode(child)):
+				// Code and TeX are written verbatim
+			default:
+				walk(child)
+			}
+		}
+	}
+	for _, node := range selec.Nodes {
+		walk(node)
+	}
+}
+
+// isMathNode reports whether an element is a math placeholder left by normalizeMath
+func isMathNode(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == "data-math" {
+			return true
+		}
+	}
+	return false
+}
+
+// removeImages removes the images of the document, leaving one space where an image sat between
+// words
+func removeImages(selec *goquery.Selection) {
+	selec.Find("img").Each(func(i int, img *goquery.Selection) {
+		node := img.Get(0)
+		prev, next := node.PrevSibling, node.NextSibling
+		if prev != nil && next != nil && prev.Type == html.TextNode && next.Type == html.TextNode &&
+			strings.TrimRight(prev.Data, " \t\n") != prev.Data {
+			next.Data = strings.TrimLeft(next.Data, " \t\n")
+		}
+		img.Remove()
+	})
+}
+
+// clampHeadingLevels renames headings deeper than maxLevel to that level, keeping their attributes
+func clampHeadingLevels(selec *goquery.Selection, maxLevel int) {
+	for level := maxLevel + 1; level <= 6; level++ {
+		selec.Find("h" + strconv.Itoa(level)).Each(func(i int, heading *goquery.Selection) {
+			node := heading.Get(0)
+			node.Data = "h" + strconv.Itoa(maxLevel)
+			node.DataAtom = atom.Lookup([]byte(node.Data))
+		})
+	}
+}
+
+var (
+	// markdownRawBlockPattern matches the first line of blocks that are never wrapped: headings,
+	// tables, HTML, blockquotes, display math and link reference definitions
+	markdownRawBlockPattern = regexp.MustCompile(`^(?:#|\||<|>|\$\$|\[[^\]^][^\]]*\]:)`)
+	// markdownLinePrefixPattern matches list markers, task checkboxes and footnote labels, which
+	// wrapped lines are indented past
+	markdownLinePrefixPattern = regexp.MustCompile(`^(?:(?:[-*+]|\d+[.)])(?: \[[ xX]\])? |\[\^[^\]]+\]: )`)
+	// markdownBlockStartPattern matches words that would start a new block or turn the previous
+	// line into a heading when placed at the start of a line
+	markdownBlockStartPattern = regexp.MustCompile("^(?:[-*+>]|#{1,6}|\\d+[.)]|[=-]+|[*_]{3,}|```.*|~~~.*)$")
+)
+
+// wrapMarkdown hard-wraps paragraphs, list items and footnotes at width characters. Code,
+// headings, tables, HTML, blockquotes and display math are left as they are.
+func wrapMarkdown(markdown string, width int) string {
+	if width <= 0 {
+		return markdown
+	}
+
+	lines := strings.Split(markdown, "\n")
+	result := make([]string, 0, len(lines))
+	fence := ""
+	raw := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		case trimmed == "":
+			raw = false
+		case raw:
+		case (i == 0 || strings.TrimSpace(lines[i-1]) == "") && markdownRawBlockPattern.MatchString(trimmed):
+			raw = true
+		case setextUnderlinePattern.MatchString(trimmed) && i > 0:
+		case i+1 < len(lines) && setextUnderlinePattern.MatchString(strings.TrimSpace(lines[i+1])):
+		default:
+			result = append(result, wrapMarkdownLine(line, width)...)
+			continue
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+// wrapMarkdownLine breaks a line between words, indenting continuation lines past its list
+// marker. Words that would start a new block stay at the end of the previous line, inline code
+// spans are never broken and a trailing hard line break is kept.
+func wrapMarkdownLine(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	prefix := line[:indent] + markdownLinePrefixPattern.FindString(line[indent:])
+	continuation := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+
+	var lines []string
+	current, length, empty := prefix, utf8.RuneCountInString(prefix), true
+	for _, word := range markdownWords(line[len(prefix):]) {
+		wordLength := utf8.RuneCountInString(word)
+		switch {
+		case empty:
+			current += word
+			length += wordLength
+		case length+1+wordLength > width && !markdownBlockStartPattern.MatchString(word):
+			lines = append(lines, current)
+			current, length = continuation+word, len(continuation)+wordLength
+		default:
+			current += " " + word
+			length += 1 + wordLength
+		}
+		empty = false
+	}
+	if strings.HasSuffix(line, "  ") {
+		current += "  "
+	}
+	return append(lines, current)
+}
+
+// markdownWords splits text into words at spaces and tabs, keeping inline code spans, whose
+// spaces are significant, inside a single word
+func markdownWords(text string) []string {
+	var words []string
+	start := -1
+	for i := 0; i < len(text); {
+		switch text[i] {
+		case ' ', '\t':
+			if start >= 0 {
+				words = append(words, text[start:i])
+				start = -1
+			}
+			i++
+		case '`':
+			if start < 0 {
+				start = i
+			}
+			run := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			i += run
+			if end := closingBacktickRun(text[i:], run); end >= 0 {
+				i += end
+			}
+		default:
+			if start < 0 {
+				start = i
+			}
+			i++
+		}
+	}
+	if start >= 0 {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// closingBacktickRun returns the offset just past the first run of exactly n backticks in text,
+// which closes a code span opened by n backticks, or -1 when there is none
+func closingBacktickRun(text string, n int) int {
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		run := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+		if run == n {
+			return i + run
+		}
+		i += run
+	}
+	return -1
+}
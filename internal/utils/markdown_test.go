@@ -0,0 +1,366 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestConvertToMarkdownFlavors(t *testing.T) {
+	tests := []struct {
+		name   string
+		html   string
+		flavor string
+		want   string
+	}{
+		{
+			name:   "gfm table with alignment",
+			html:   `<table><thead><tr><th data-align="left">Name</th><th data-align="center">Qty</th><th data-align="right">Price</th></tr></thead><tbody><tr><td>Apple</td><td>3</td><td>$1</td></tr></tbody></table>`,
+			flavor: MarkdownFlavorGFM,
+			want:   "| Name | Qty | Price |\n| :-- | :-: | --: |\n| Apple | 3 | \\$1 |",
+		},
+		{
+			name:   "gfm strikethrough",
+			html:   `<p>Was <del>$2</del> now <s>$1.50</s> $1</p>`,
+			flavor: MarkdownFlavorGFM,
+			want:   `Was ~~\$2~~ now ~~\$1.50~~ \$1`,
+		},
+		{
+			name:   "gfm task list",
+			html:   `<ul><li><span data-task="checked"></span> Write tests</li><li><span data-task="open"></span> Ship it</li></ul>`,
+			flavor: MarkdownFlavorGFM,
+			want:   "- [x] Write tests\n- [ ] Ship it",
+		},
+		{
+			name:   "gfm colspan falls back to html",
+			html:   "<table><tbody><tr><th colspan=\"2\">Merged</th></tr>\n\n<tr><td>a</td><td>b</td></tr></tbody></table>",
+			flavor: MarkdownFlavorGFM,
+			want:   "<table><tbody><tr><th colspan=\"2\">Merged</th></tr>\n<tr><td>a</td><td>b</td></tr></tbody></table>",
+		},
+		{
+			name:   "gfm block content falls back to html",
+			html:   `<table><tr><th>Step</th></tr><tr><td><ul><li>one</li></ul></td></tr></table>`,
+			flavor: MarkdownFlavorGFM,
+			want:   "<table><tbody><tr><th>Step</th></tr><tr><td><ul><li>one</li></ul></td></tr></tbody></table>",
+		},
+		{
+			name:   "currency next to math",
+			html:   `<p>It costs $5 and $10 today, or <span data-math="inline" data-tex="x^2">x²</span> in <code>$HOME</code>.</p><pre><code>echo $PATH</code></pre>`,
+			flavor: MarkdownFlavorGFM,
+			want:   "It costs \\$5 and \\$10 today, or $x^2$ in `$HOME`.\n\n```\necho $PATH\n```",
+		},
+		{
+			name:   "dollar signs in embedded tables stay plain",
+			html:   `<table><tr><th colspan="2">Prices</th></tr><tr><td>Apple</td><td>$1</td></tr></table>`,
+			flavor: MarkdownFlavorGFM,
+			want:   `<table><tbody><tr><th colspan="2">Prices</th></tr><tr><td>Apple</td><td>$1</td></tr></tbody></table>`,
+		},
+		{
+			name:   "commonmark table stays html",
+			html:   `<table><tr><th data-align="right">Qty</th></tr><tr><td>3</td></tr></table>`,
+			flavor: MarkdownFlavorCommonMark,
+			want:   `<table><tbody><tr><th align="right">Qty</th></tr><tr><td>3</td></tr></tbody></table>`,
+		},
+		{
+			name:   "commonmark strikethrough stays html",
+			html:   `<p>Was <del>$2</del> $1</p>`,
+			flavor: MarkdownFlavorCommonMark,
+			want:   `Was <del>\$2</del> \$1`,
+		},
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ac.convertToMarkdown(tt.html, MarkdownOptions{Flavor: tt.flavor}); got != tt.want {
+				t.Errorf("convertToMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreserveMarkdownHints(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<body>
+		<table><tr><th align="CENTER">a</th><th style="color: red; text-align: right">b</th><td align="justify">c</td></tr></table>
+		<ul><li><input type="checkbox" checked> done</li></ul>
+		<form><input type="checkbox"></form>
+	</body>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	ac.preserveMarkdownHints(doc)
+
+	var aligns []string
+	doc.Find("th, td").Each(func(i int, cell *goquery.Selection) {
+		aligns = append(aligns, cell.AttrOr("data-align", ""))
+	})
+	if strings.Join(aligns, ",") != "center,right," {
+		t.Errorf("Expected data-align center,right and none, got %q", aligns)
+	}
+	if got := doc.Find(`li > span[data-task="checked"]`).Length(); got != 1 {
+		t.Errorf("Expected the list checkbox to become a task marker, got %d markers", got)
+	}
+	if got := doc.Find(`form input[type="checkbox"]`).Length(); got != 1 {
+		t.Errorf("Expected checkboxes outside lists to be left alone, got %d", got)
+	}
+}
+
+func TestCleanArticleMarkdownFlavor(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>Fruit Prices</title></head>
+<body>
+	<article>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<table>
+			<thead><tr><th>Fruit</th><th style="text-align: right">Price</th></tr></thead>
+			<tbody><tr><td>Apple</td><td align="right"><del>$2</del> $1</td></tr></tbody>
+		</table>
+		<ul>
+			<li><input type="checkbox" checked disabled> Buy apples</li>
+			<li><input type="checkbox" disabled> Buy pears</li>
+		</ul>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+	for _, want := range []string{"| Fruit | Price |\n| --- | --: |\n| Apple | ~~\\$2~~ \\$1 |", "- [x] Buy apples\n- [ ] Buy pears"} {
+		if !strings.Contains(article.Markdown, want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, article.Markdown)
+		}
+	}
+
+	options := DefaultArticleOptions()
+	options.Markdown.Flavor = MarkdownFlavorCommonMark
+	article, err = ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+	if !strings.Contains(article.Markdown, `<th align="right">Price</th>`) || strings.Contains(article.Markdown, "~~") {
+		t.Errorf("Expected an HTML table without strikethrough syntax, got:\n%s", article.Markdown)
+	}
+}
+
+func TestConvertToMarkdownOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		options MarkdownOptions
+		want    string
+	}{
+		{
+			name:    "setext headings",
+			html:    `<h1>Title</h1><h2>Part</h2><h3>Detail</h3>`,
+			options: MarkdownOptions{HeadingStyle: MarkdownHeadingSetext},
+			want:    "Title\n=====\n\nPart\n----\n\n### Detail",
+		},
+		{
+			name:    "star bullets",
+			html:    `<ul><li>one</li><li>two</li></ul>`,
+			options: MarkdownOptions{BulletListMarker: "*"},
+			want:    "* one\n* two",
+		},
+		{
+			name:    "referenced links",
+			html:    `<p>Read <a href="https://example.com/a">the docs</a> and <a href="https://example.com/b">the FAQ</a>.</p>`,
+			options: MarkdownOptions{LinkStyle: MarkdownLinkReferenced},
+			want:    "Read [the docs][1] and [the FAQ][2].\n\n[1]: https://example.com/a\n[2]: https://example.com/b",
+		},
+		{
+			name:    "dropped images",
+			html:    `<p>Before <img src="https://example.com/a.png" alt="A chart"> after</p><p data-embed="youtube" data-embed-url="https://www.youtube.com/watch?v=dQw4w9WgXcQ" data-embed-thumbnail="https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg"><a href="https://www.youtube.com/watch?v=dQw4w9WgXcQ">YouTube video</a></p>`,
+			options: MarkdownOptions{DropImages: true},
+			want:    "Before after\n\n[YouTube video](https://www.youtube.com/watch?v=dQw4w9WgXcQ)",
+		},
+		{
+			name:    "max heading level",
+			html:    `<h1 id="a">A</h1><h3>B</h3><h5>C</h5>`,
+			options: MarkdownOptions{MaxHeadingLevel: 2},
+			want:    "# A\n\n## B\n\n## C",
+		},
+		{
+			name:    "wrapped paragraphs",
+			html:    `<h2>A heading that is longer than the wrap width</h2><p>The quick brown fox jumps over the lazy dog.</p><ol><li>An ordered item that wraps around</li></ol>`,
+			options: MarkdownOptions{WrapWidth: 20},
+			want:    "## A heading that is longer than the wrap width\n\nThe quick brown fox\njumps over the lazy\ndog.\n\n1. An ordered item\n   that wraps around",
+		},
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ac.convertToMarkdown(tt.html, tt.options); got != tt.want {
+				t.Errorf("convertToMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "code and tables are left alone",
+			markdown: "```\nsome very long line of code that should stay\n```\n\n| a very long table cell | b |\n| --- | --- |",
+			want:     "```\nsome very long line of code that should stay\n```\n\n| a very long table cell | b |\n| --- | --- |",
+		},
+		{
+			name:     "task list items indent past the checkbox",
+			markdown: "- [ ] write the wrapping tests",
+			want:     "- [ ] write the\n      wrapping tests",
+		},
+		{
+			name:     "words that would start a block stay on the line",
+			markdown: "costs about twenty - dollars",
+			want:     "costs about twenty -\ndollars",
+		},
+		{
+			name:     "footnote definitions",
+			markdown: "[^1]: A note that needs wrapping",
+			want:     "[^1]: A note that\n      needs wrapping",
+		},
+		{
+			name:     "hard line breaks are kept",
+			markdown: "first line that wraps here  \nnext",
+			want:     "first line that\nwraps here  \nnext",
+		},
+		{
+			name:     "code spans are not broken",
+			markdown: "call `a  b   c` then more",
+			want:     "call `a  b   c` then\nmore",
+		},
+		{
+			name:     "code spans with double backticks",
+			markdown: "use ``a ` b`` for ticks",
+			want:     "use ``a ` b`` for\nticks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapMarkdown(tt.markdown, 20); got != tt.want {
+				t.Errorf("wrapMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownConverterCache(t *testing.T) {
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	options := DefaultMarkdownOptions()
+	converter := ac.markdownConverter(options)
+
+	wrapped := options
+	wrapped.WrapWidth = 72
+	if ac.markdownConverter(wrapped) != converter {
+		t.Error("Expected option sets that only differ in wrap width to share a converter")
+	}
+	setext := options
+	setext.HeadingStyle = MarkdownHeadingSetext
+	if ac.markdownConverter(setext) == converter {
+		t.Error("Expected a separate converter for a different heading style")
+	}
+	invalid := options
+	invalid.BulletListMarker = "x"
+	if ac.markdownConverter(invalid) != converter {
+		t.Error("Expected invalid options to fall back to the default converter")
+	}
+}
+
+func TestCleanArticleMarkdownOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>A Long Guide</title></head>
+<body>
+	<article>
+		<h2>Installation</h2>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<h4>Requirements</h4>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+		<p><img src="/diagram.png" alt="Architecture diagram"></p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	options := DefaultArticleOptions()
+	options.Markdown.HeadingStyle = MarkdownHeadingSetext
+	options.Markdown.MaxHeadingLevel = 2
+	options.Markdown.DropImages = true
+	options.Chunking = &ChunkOptions{TargetSize: 1000}
+	article, err := ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"<a id=\"installation\"></a>\n\nInstallation\n------------",
+		"<a id=\"requirements\"></a>\n\nRequirements\n------------",
+	} {
+		if !strings.Contains(article.Markdown, want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, article.Markdown)
+		}
+	}
+	if strings.Contains(article.Markdown, "diagram.png") {
+		t.Errorf("Expected images to be dropped, got:\n%s", article.Markdown)
+	}
+	if len(article.Chunks) == 0 || article.Chunks[len(article.Chunks)-1].HeadingPath[0] != "Requirements" {
+		t.Errorf("Expected the setext headings to start chunk sections, got %+v", article.Chunks)
+	}
+}
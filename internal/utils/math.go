@@ -0,0 +1,439 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Math display modes of the markers left by normalizeMath
+const (
+	mathInline  = "inline"
+	mathDisplay = "display"
+)
+
+// mathJaxRenderSelectors match the rendered output MathJax 2 places next to its source scripts
+var mathJaxRenderSelectors = []string{
+	".MathJax_Preview", ".MathJax", ".MathJax_Display", ".MathJax_SVG", ".MathJax_SVG_Display",
+	".MathJax_CHTML", ".MathJax_MathML", ".MJXc-display",
+}
+
+// texAnnotationEncodings are the MathML annotation encodings holding TeX source
+var texAnnotationEncodings = []string{"application/x-tex", "TeX", "text/x-tex", "application/x-latex"}
+
+// texStyleWrapperPattern matches the {\displaystyle ...} wrapper Wikipedia puts around formulas
+var texStyleWrapperPattern = regexp.MustCompile(`^\{\\(?:displaystyle|textstyle|scriptstyle)\s*(.*)\}$`)
+
+// normalizeMath replaces MathJax, KaTeX, MathML and LaTeX image markup with markers holding the
+// TeX source and a plain-text rendering. It runs before removeUnwantedElements, which would
+// remove MathJax source scripts.
+func (ac *ArticleCleaner) normalizeMath(doc *goquery.Document) {
+	count := 0
+	replace := func(s *goquery.Selection, tex string, display bool) {
+		tex = cleanTeX(tex)
+		if tex == "" {
+			return
+		}
+		s.ReplaceWithHtml(mathMarkerHTML(tex, display))
+		count++
+	}
+
+	// KaTeX keeps the source in a MathML annotation next to its HTML rendering
+	doc.Find(".katex-display, .katex").Each(func(i int, katex *goquery.Selection) {
+		if katex.ParentsFiltered(".katex-display").Length() > 0 {
+			return
+		}
+		replace(katex, mathMLSource(katex.Find("math").First()), katex.HasClass("katex-display"))
+	})
+
+	// MathJax 3 only keeps assistive MathML
+	doc.Find("mjx-container").Each(func(i int, container *goquery.Selection) {
+		replace(container, mathMLSource(container.Find("math").First()), container.AttrOr("display", "") == "true")
+	})
+
+	// MathJax 2 keeps the source in script tags and renders into the elements before them
+	scripts := doc.Find(`script[type^="math/tex"]`)
+	scripts.Each(func(i int, script *goquery.Selection) {
+		if id, ok := script.Attr("id"); ok && !strings.ContainsAny(id, `"\`) {
+			frame := doc.Find(`[id="` + id + `-Frame"]`)
+			if wrapper := frame.Closest(".MathJax_Display, .MathJax_SVG_Display, .MJXc-display"); wrapper.Length() > 0 {
+				frame = wrapper
+			}
+			frame.Remove()
+		}
+		if preview := script.Prev(); preview.HasClass("MathJax_Preview") {
+			preview.Remove()
+		}
+		replace(script, script.Text(), strings.Contains(script.AttrOr("type", ""), "mode=display"))
+	})
+	if scripts.Length() > 0 {
+		doc.Find(strings.Join(mathJaxRenderSelectors, ", ")).Remove()
+	}
+
+	// Wikipedia pairs MathML carrying alttext with a fallback image
+	doc.Find(".mwe-math-element").Each(func(i int, element *goquery.Selection) {
+		tex := mathMLSource(element.Find("math").First())
+		if tex == "" {
+			tex = element.Find("img").AttrOr("alt", "")
+		}
+		display := element.Find(".mwe-math-fallback-image-display, .mwe-math-mathml-display").Length() > 0
+		replace(element, tex, display)
+	})
+
+	doc.Find("math").Each(func(i int, math *goquery.Selection) {
+		replace(math, mathMLSource(math), math.AttrOr("display", "") == "block")
+	})
+
+	// LaTeX images from WordPress and similar plugins keep the source in the alt text
+	doc.Find("img.latex, img.tex").Each(func(i int, img *goquery.Selection) {
+		replace(img, img.AttrOr("alt", ""), false)
+	})
+
+	if count > 0 {
+		ac.logger.Debugw("Normalized math", "count", count)
+	}
+}
+
+// mathMarkerHTML renders the marker for a formula: the TeX source in data-tex for markdown and a
+// plain-text rendering as content for the text
+func mathMarkerHTML(tex string, display bool) string {
+	mode := mathInline
+	if display {
+		mode = mathDisplay
+	}
+	return `<span data-math="` + mode + `" data-tex="` + html.EscapeString(tex) + `">` + html.EscapeString(texToText(tex)) + `</span>`
+}
+
+// mathMarkdown renders a math marker as $...$ or a $$ block
+func mathMarkdown(marker *goquery.Selection) (string, bool) {
+	mode, ok := marker.Attr("data-math")
+	if !ok {
+		return "", false
+	}
+	tex := marker.AttrOr("data-tex", "")
+	if mode == mathDisplay {
+		return "\n\n$$\n" + tex + "\n$$\n\n", true
+	}
+	return "$" + tex + "$", true
+}
+
+// cleanTeX trims TeX source and removes display style wrappers
+func cleanTeX(tex string) string {
+	tex = strings.TrimSpace(tex)
+	if match := texStyleWrapperPattern.FindStringSubmatch(tex); match != nil {
+		tex = strings.TrimSpace(match[1])
+	}
+	return tex
+}
+
+// mathMLSource returns the TeX source of a MathML element from its TeX annotation or alttext,
+// falling back to converting the MathML itself
+func mathMLSource(math *goquery.Selection) string {
+	if math.Length() == 0 {
+		return ""
+	}
+	for _, encoding := range texAnnotationEncodings {
+		if annotation := math.Find(`annotation[encoding="` + encoding + `"]`); annotation.Length() > 0 {
+			return annotation.First().Text()
+		}
+	}
+	if alt, ok := math.Attr("alttext"); ok && strings.TrimSpace(alt) != "" {
+		return alt
+	}
+	return strings.TrimSpace(mathMLToTeX(math))
+}
+
+// mathMLToTeX converts presentation MathML to TeX
+func mathMLToTeX(s *goquery.Selection) string {
+	children := s.Children()
+	child := func(i int) string {
+		return mathMLToTeX(children.Eq(i))
+	}
+
+	switch goquery.NodeName(s) {
+	case "mi", "mn", "mo", "ms":
+		// Invisible function application and multiplication operators have no TeX
+		return strings.TrimSpace(strings.Trim(s.Text(), "\u2061\u2062\u2063\u2064"))
+	case "mtext":
+		return `\text{` + s.Text() + `}`
+	case "mspace":
+		return " "
+	case "annotation", "annotation-xml", "mphantom":
+		return ""
+	case "msup":
+		return texGroup(child(0)) + "^" + texGroup(child(1))
+	case "msub":
+		return texGroup(child(0)) + "_" + texGroup(child(1))
+	case "msubsup", "munderover":
+		return texGroup(child(0)) + "_" + texGroup(child(1)) + "^" + texGroup(child(2))
+	case "munder":
+		return texGroup(child(0)) + "_" + texGroup(child(1))
+	case "mover":
+		return `\overset{` + child(1) + `}{` + child(0) + `}`
+	case "mfrac":
+		return `\frac{` + child(0) + `}{` + child(1) + `}`
+	case "msqrt":
+		return `\sqrt{` + mathMLRow(children) + `}`
+	case "mroot":
+		return `\sqrt[` + child(1) + `]{` + child(0) + `}`
+	case "mfenced":
+		separator := s.AttrOr("separators", ",")
+		var parts []string
+		children.Each(func(i int, c *goquery.Selection) {
+			parts = append(parts, mathMLToTeX(c))
+		})
+		return s.AttrOr("open", "(") + strings.Join(parts, separator) + s.AttrOr("close", ")")
+	case "mtable":
+		var rows []string
+		children.Each(func(i int, row *goquery.Selection) {
+			var cells []string
+			row.Children().Each(func(j int, cell *goquery.Selection) {
+				cells = append(cells, mathMLRow(cell.Children()))
+			})
+			rows = append(rows, strings.Join(cells, " & "))
+		})
+		return `\begin{matrix}` + strings.Join(rows, ` \\ `) + `\end{matrix}`
+	}
+	return mathMLRow(children)
+}
+
+// mathMLRow converts a sequence of MathML elements, separating words such as function names
+// from the letters next to them
+func mathMLRow(children *goquery.Selection) string {
+	var b strings.Builder
+	previous := ""
+	children.Each(func(i int, c *goquery.Selection) {
+		tex := mathMLToTeX(c)
+		last, _ := utf8.DecodeLastRuneInString(previous)
+		if next := firstRune(tex); unicode.IsLetter(last) && unicode.IsLetter(next) &&
+			(utf8.RuneCountInString(previous) > 1 || utf8.RuneCountInString(tex) > 1) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tex)
+		if tex != "" {
+			previous = tex
+		}
+	})
+	return b.String()
+}
+
+// texGroup wraps TeX in braces unless it is a single character
+func texGroup(tex string) string {
+	if utf8.RuneCountInString(tex) == 1 {
+		return tex
+	}
+	return "{" + tex + "}"
+}
+
+// texSymbols maps TeX commands to the characters they render
+var texSymbols = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "φ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω", "Gamma": "Γ", "Delta": "Δ", "Theta": "Θ",
+	"Lambda": "Λ", "Xi": "Ξ", "Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ",
+	"Psi": "Ψ", "Omega": "Ω",
+	"cdot": "·", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆",
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "approx": "≈",
+	"equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝", "ll": "≪", "gg": "≫",
+	"infty": "∞", "partial": "∂", "nabla": "∇", "sum": "∑", "prod": "∏", "int": "∫",
+	"oint": "∮", "in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆",
+	"supset": "⊃", "supseteq": "⊇", "cup": "∪", "cap": "∩", "emptyset": "∅", "varnothing": "∅",
+	"forall": "∀", "exists": "∃", "neg": "¬", "lnot": "¬", "land": "∧", "wedge": "∧",
+	"lor": "∨", "vee": "∨", "to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←",
+	"leftrightarrow": "↔", "Rightarrow": "⇒", "implies": "⇒", "Leftarrow": "⇐",
+	"Leftrightarrow": "⇔", "iff": "⇔", "mapsto": "↦", "ldots": "…", "dots": "…",
+	"cdots": "⋯", "vdots": "⋮", "ddots": "⋱", "circ": "∘", "bullet": "•", "degree": "°",
+	"prime": "′", "hbar": "ℏ", "ell": "ℓ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"mid": "|", "vert": "|", "Vert": "‖", "perp": "⊥", "parallel": "∥", "angle": "∠",
+	"triangle": "△", "therefore": "∴", "because": "∵",
+	"quad": " ", "qquad": " ", ",": " ", ";": " ", ":": " ", ">": " ", " ": " ", "!": "",
+	"\\": " ", "{": "{", "}": "}", "%": "%", "$": "$", "&": "&", "_": "_", "#": "#",
+}
+
+// texFontCommands are TeX commands whose argument is rendered as is
+var texFontCommands = map[string]bool{
+	"text": true, "textrm": true, "textit": true, "textbf": true, "mathrm": true, "mathit": true,
+	"mathbf": true, "mathsf": true, "mathtt": true, "mathcal": true, "mathfrak": true,
+	"boldsymbol": true, "bm": true, "operatorname": true, "mbox": true, "hbox": true,
+	"overline": true, "underline": true, "hat": true, "bar": true, "vec": true, "tilde": true,
+	"dot": true, "ddot": true, "widehat": true, "widetilde": true, "displaystyle": true,
+	"textstyle": true,
+}
+
+// texIgnoredCommands are sizing and layout TeX commands without text of their own
+var texIgnoredCommands = map[string]bool{
+	"left": true, "right": true, "big": true, "Big": true, "bigg": true, "Bigg": true,
+	"bigl": true, "bigr": true, "Bigl": true, "Bigr": true, "limits": true, "nolimits": true,
+}
+
+// texDoubleStruck maps \mathbb letters to their double-struck characters
+var texDoubleStruck = map[string]string{"R": "ℝ", "N": "ℕ", "Z": "ℤ", "Q": "ℚ", "C": "ℂ", "P": "ℙ", "H": "ℍ"}
+
+// Unicode superscript and subscript characters used by texToText
+var (
+	superscripts = map[rune]rune{
+		'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸',
+		'9': '⁹', '+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ',
+		'−': '⁻', '′': '′',
+	}
+	subscripts = map[rune]rune{
+		'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈',
+		'9': '₉', '+': '₊', '-': '₋', '=': '₌', '(': '₍', ')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'o': 'ₒ',
+		'x': 'ₓ', 'h': 'ₕ', 'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ', 'p': 'ₚ', 's': 'ₛ', 't': 'ₜ',
+		'i': 'ᵢ', 'j': 'ⱼ', 'r': 'ᵣ', 'u': 'ᵤ', 'v': 'ᵥ',
+	}
+)
+
+// texToText renders TeX as readable plain text, e.g. "\frac{a+b}{2}" as "(a+b)/2" and
+// "x^2 \leq \alpha" as "x² ≤ α"
+func texToText(tex string) string {
+	p := &texParser{tex: tex}
+	return strings.Join(strings.Fields(p.parse(false)), " ")
+}
+
+// texParser is a minimal TeX reader for texToText
+type texParser struct {
+	tex string
+	i   int
+}
+
+// parse renders TeX up to the end of the input, or the closing brace of the current group
+func (p *texParser) parse(group bool) string {
+	var b strings.Builder
+	for p.i < len(p.tex) {
+		c := p.tex[p.i]
+		switch c {
+		case '}':
+			p.i++
+			if group {
+				return b.String()
+			}
+		case '{':
+			p.i++
+			b.WriteString(p.parse(true))
+		case '^', '_':
+			p.i++
+			b.WriteString(texScript(p.argument(), c == '^'))
+		case '~', '&':
+			p.i++
+			b.WriteByte(' ')
+		case '\\':
+			b.WriteString(p.command())
+		default:
+			r, size := utf8.DecodeRuneInString(p.tex[p.i:])
+			p.i += size
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// argument renders the next group, command or character
+func (p *texParser) argument() string {
+	for p.i < len(p.tex) && p.tex[p.i] == ' ' {
+		p.i++
+	}
+	if p.i >= len(p.tex) {
+		return ""
+	}
+	switch p.tex[p.i] {
+	case '{':
+		p.i++
+		return p.parse(true)
+	case '\\':
+		return p.command()
+	}
+	r, size := utf8.DecodeRuneInString(p.tex[p.i:])
+	p.i += size
+	return string(r)
+}
+
+// command renders the command at the current position, including its arguments
+func (p *texParser) command() string {
+	p.i++ // backslash
+	start := p.i
+	for p.i < len(p.tex) && isASCIILetter(p.tex[p.i]) {
+		p.i++
+	}
+	if p.i == start && p.i < len(p.tex) {
+		_, size := utf8.DecodeRuneInString(p.tex[p.i:])
+		p.i += size
+	}
+	name := p.tex[start:p.i]
+
+	switch {
+	case name == "frac" || name == "dfrac" || name == "tfrac":
+		numerator, denominator := p.argument(), p.argument()
+		return texParenthesize(numerator) + "/" + texParenthesize(denominator)
+	case name == "sqrt":
+		if p.i < len(p.tex) && p.tex[p.i] == '[' {
+			if end := strings.IndexByte(p.tex[p.i:], ']'); end >= 0 {
+				p.i += end + 1
+			}
+		}
+		return "√" + texParenthesize(p.argument())
+	case name == "mathbb":
+		argument := p.argument()
+		if symbol, ok := texDoubleStruck[argument]; ok {
+			return symbol
+		}
+		return argument
+	case name == "begin" || name == "end":
+		p.argument()
+		return " "
+	case texFontCommands[name]:
+		return p.argument()
+	case texIgnoredCommands[name]:
+		if p.i < len(p.tex) && p.tex[p.i] == '.' {
+			p.i++
+		}
+		return ""
+	}
+	if symbol, ok := texSymbols[name]; ok {
+		return symbol
+	}
+	// Function names such as \sin and \log, and unknown commands, keep their name
+	return name
+}
+
+// texScript renders a superscript or subscript with Unicode characters when all of them have
+// one, otherwise as ^(...) or _(...)
+func texScript(text string, superscript bool) string {
+	table, marker := subscripts, "_"
+	if superscript {
+		table, marker = superscripts, "^"
+	}
+	var b strings.Builder
+	for _, r := range text {
+		mapped, ok := table[r]
+		if !ok && utf8.RuneCountInString(text) > 1 {
+			return marker + "(" + strings.TrimSpace(text) + ")"
+		}
+		if !ok {
+			return marker + text
+		}
+		b.WriteRune(mapped)
+	}
+	return b.String()
+}
+
+// texParenthesize wraps text in parentheses unless it is a single character or plain word
+func texParenthesize(text string) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= 1 || !strings.ContainsAny(text, "+-−*/=<>·×÷ ,") {
+		return text
+	}
+	return "(" + text + ")"
+}
+
+// isASCIILetter reports whether c is an ASCII letter
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestTexToText(t *testing.T) {
+	tests := []struct {
+		tex  string
+		want string
+	}{
+		{`E = mc^2`, "E = mc²"},
+		{`\frac{a+b}{2}`, "(a+b)/2"},
+		{`\frac12`, "1/2"},
+		{`x_{i}^{2} \leq \alpha`, "xᵢ² ≤ α"},
+		{`\sum_{i=1}^{n} i`, "∑ᵢ₌₁ⁿ i"},
+		{`\sqrt{x^2 + 1}`, "√(x² + 1)"},
+		{`e^{i\pi} + 1 = 0`, "e^(iπ) + 1 = 0"},
+		{`\left( \mathbb{R}, \text{max} \right)`, "( ℝ, max )"},
+		{`\sin\theta \cdot \cos \theta`, "sinθ · cos θ"},
+		{`a \\ b`, "a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tex, func(t *testing.T) {
+			if got := texToText(tt.tex); got != tt.want {
+				t.Errorf("texToText(%q) = %q, want %q", tt.tex, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeMath(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "katex inline",
+			html: `<p>Energy <span class="katex"><span class="katex-mathml"><math><semantics><mrow><mi>E</mi><mo>=</mo><mi>m</mi><msup><mi>c</mi><mn>2</mn></msup></mrow><annotation encoding="application/x-tex">E = mc^2</annotation></semantics></math></span><span class="katex-html" aria-hidden="true"><span class="base">E=mc2</span></span></span>.</p>`,
+			want: `<p>Energy <span data-math="inline" data-tex="E = mc^2">E = mc²</span>.</p>`,
+		},
+		{
+			name: "katex display",
+			html: `<p><span class="katex-display"><span class="katex"><span class="katex-mathml"><math display="block"><semantics><mi>x</mi><annotation encoding="application/x-tex">x</annotation></semantics></math></span><span class="katex-html">x</span></span></span></p>`,
+			want: `<p><span data-math="display" data-tex="x">x</span></p>`,
+		},
+		{
+			name: "mathjax 2",
+			html: `<p>Let <span class="MathJax_Preview" style="color: inherit;"></span><span class="MathJax" id="MathJax-Element-1-Frame" tabindex="0"><nobr><span class="math">αβ</span></nobr><span class="MJX_Assistive_MathML"><math><mi>α</mi></math></span></span><script type="math/tex" id="MathJax-Element-1">\alpha \beta</script> hold.</p>
+<div class="MathJax_Display"><span class="MathJax" id="MathJax-Element-2-Frame">∫</span></div><script type="math/tex; mode=display" id="MathJax-Element-2">\int_0^1 f</script>`,
+			want: `<p>Let <span data-math="inline" data-tex="\alpha \beta">α β</span> hold.</p>
+<span data-math="display" data-tex="\int_0^1 f">∫₀¹ f</span>`,
+		},
+		{
+			name: "mathjax 3",
+			html: `<mjx-container class="MathJax" jax="CHTML" display="true"><mjx-math aria-hidden="true"><mjx-mi>x</mjx-mi></mjx-math><mjx-assistive-mml display="block"><math display="block"><mfrac><mn>1</mn><mi>x</mi></mfrac></math></mjx-assistive-mml></mjx-container>`,
+			want: `<span data-math="display" data-tex="\frac{1}{x}">1/x</span>`,
+		},
+		{
+			name: "wikipedia",
+			html: `<span class="mwe-math-element"><span class="mwe-math-mathml-inline mwe-math-mathml-a11y" style="display: none;"><math alttext="{\displaystyle a^{2}+b^{2}}"><mrow><msup><mi>a</mi><mn>2</mn></msup></mrow></math></span><img src="https://wikimedia.org/api/rest_v1/media/math/render/svg/abc" class="mwe-math-fallback-image-inline" alt="{\displaystyle a^{2}+b^{2}}"></span>`,
+			want: `<span data-math="inline" data-tex="a^{2}+b^{2}">a²+b²</span>`,
+		},
+		{
+			name: "plain mathml",
+			html: `<math><mi>sin</mi><mo>⁡</mo><mi>x</mi><mo>+</mo><msqrt><mi>y</mi></msqrt></math>`,
+			want: `<span data-math="inline" data-tex="sin x+\sqrt{y}">sin x+√y</span>`,
+		},
+		{
+			name: "latex image",
+			html: `<img src="https://s0.wp.com/latex.php?latex=x%5E2" alt="x^2" class="latex">`,
+			want: `<span data-math="inline" data-tex="x^2">x²</span>`,
+		},
+	}
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<body>" + tt.html + "</body>"))
+			if err != nil {
+				t.Fatalf("Failed to parse HTML: %v", err)
+			}
+			ac.normalizeMath(doc)
+			got, _ := doc.Find("body").Html()
+			if got != tt.want {
+				t.Errorf("normalizeMath() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanArticleMath(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>Mass and Energy</title></head>
+<body>
+	<article>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		The famous relation <span class="MathJax_Preview"></span><span class="MathJax" id="MathJax-Element-1-Frame">E=mc2</span><script type="math/tex" id="MathJax-Element-1">E = mc^2</script> links mass and energy, and readability needs enough text to pick this paragraph.</p>
+		<p>The rest energy of a body follows from integrating the work done on it:</p>
+		<div class="MathJax_Display"><span class="MathJax" id="MathJax-Element-2-Frame">∫</span></div><script type="math/tex; mode=display" id="MathJax-Element-2">E_0 = \int_0^v F \, dx</script>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	for _, want := range []string{"relation $E = mc^2$ links", "$$\nE_0 = \\int_0^v F \\, dx\n$$"} {
+		if !strings.Contains(article.Markdown, want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, article.Markdown)
+		}
+	}
+	if !strings.Contains(article.Content, "relation E = mc² links") {
+		t.Errorf("Expected plain-text math in the content, got:\n%s", article.Content)
+	}
+	if strings.Contains(article.Content, "E=mc2") {
+		t.Errorf("Expected rendered MathJax output to be removed, got:\n%s", article.Content)
+	}
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// OEmbedData represents the oEmbed response for a page
+type OEmbedData struct {
+	Type            string `json:"type,omitempty"`
+	Title           string `json:"title,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	AuthorURL       string `json:"author_url,omitempty"`
+	ProviderName    string `json:"provider_name,omitempty"`
+	ProviderURL     string `json:"provider_url,omitempty"`
+	HTML            string `json:"html,omitempty"`
+	Width           int    `json:"width,omitempty"`
+	Height          int    `json:"height,omitempty"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+	Endpoint        string `json:"endpoint"`
+}
+
+// oembedProvider describes a well-known oEmbed provider that may not advertise discovery links
+type oembedProvider struct {
+	Name     string
+	Hosts    []string
+	Endpoint string
+}
+
+// oembedProviders is the built-in registry of well-known oEmbed providers
+var oembedProviders = []oembedProvider{
+	{Name: "YouTube", Hosts: []string{"youtube.com", "youtu.be"}, Endpoint: "https://www.youtube.com/oembed"},
+	{Name: "Vimeo", Hosts: []string{"vimeo.com"}, Endpoint: "https://vimeo.com/api/oembed.json"},
+	{Name: "Spotify", Hosts: []string{"open.spotify.com", "spotify.link"}, Endpoint: "https://open.spotify.com/oembed"},
+	{Name: "SoundCloud", Hosts: []string{"soundcloud.com"}, Endpoint: "https://soundcloud.com/oembed"},
+	{Name: "Twitter", Hosts: []string{"twitter.com", "x.com"}, Endpoint: "https://publish.twitter.com/oembed"},
+	{Name: "Flickr", Hosts: []string{"flickr.com", "flic.kr"}, Endpoint: "https://www.flickr.com/services/oembed/"},
+	{Name: "TikTok", Hosts: []string{"tiktok.com"}, Endpoint: "https://www.tiktok.com/oembed"},
+	{Name: "Reddit", Hosts: []string{"reddit.com"}, Endpoint: "https://www.reddit.com/oembed"},
+}
+
+// maxOEmbedResponseSize caps how much of an oEmbed response is read
+const maxOEmbedResponseSize = 1 << 20
+
+// oembedTimeout bounds how long an oEmbed request may take
+const oembedTimeout = 10 * time.Second
+
+// oembedClient fetches oEmbed responses
+var oembedClient = &http.Client{Timeout: oembedTimeout}
+
+// oembedResponse mirrors the oEmbed JSON payload, whose dimensions may be numbers or strings
+type oembedResponse struct {
+	Type            string          `json:"type"`
+	Title           string          `json:"title"`
+	AuthorName      string          `json:"author_name"`
+	AuthorURL       string          `json:"author_url"`
+	ProviderName    string          `json:"provider_name"`
+	ProviderURL     string          `json:"provider_url"`
+	HTML            string          `json:"html"`
+	Width           oembedDimension `json:"width"`
+	Height          oembedDimension `json:"height"`
+	ThumbnailURL    string          `json:"thumbnail_url"`
+	ThumbnailWidth  oembedDimension `json:"thumbnail_width"`
+	ThumbnailHeight oembedDimension `json:"thumbnail_height"`
+}
+
+// oembedDimension is an oEmbed width or height; non-numeric values like "100%" decode to zero
+type oembedDimension int
+
+// UnmarshalJSON accepts dimensions encoded as JSON numbers or strings
+func (d *oembedDimension) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if n, err := strconv.ParseFloat(value, 64); err == nil && n > 0 {
+		*d = oembedDimension(n)
+	} else {
+		*d = 0
+	}
+	return nil
+}
+
+// extractOEmbedData discovers and fetches oEmbed data for a page
+func (ac *ArticleCleaner) extractOEmbedData(doc *goquery.Document, baseURL *url.URL) *OEmbedData {
+	documentBase := documentBaseURL(doc, baseURL)
+	endpoint := ac.discoverOEmbedEndpoint(doc, documentBase, baseURL)
+	if endpoint == "" {
+		endpoint = lookupOEmbedEndpoint(baseURL)
+	}
+	if endpoint == "" {
+		return nil
+	}
+
+	data, err := ac.fetchOEmbed(endpoint)
+	if err != nil {
+		ac.logger.Warnw("Failed to fetch oEmbed data", "endpoint", endpoint, "error", err)
+		return nil
+	}
+
+	data.ThumbnailURL = ac.resolveURL(data.ThumbnailURL, documentBase)
+
+	ac.logger.Debugw("Extracted oEmbed data",
+		"endpoint", endpoint,
+		"type", data.Type,
+		"provider", data.ProviderName,
+	)
+
+	return data
+}
+
+// discoverOEmbedEndpoint returns the JSON oEmbed endpoint advertised by the page, if any. Only
+// endpoints on the page's own host or of a registered provider are used, so a page cannot make
+// the server request arbitrary URLs.
+func (ac *ArticleCleaner) discoverOEmbedEndpoint(doc *goquery.Document, baseURL, pageURL *url.URL) string {
+	endpoint := ""
+	doc.Find("link[rel~='alternate'][href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if !strings.EqualFold(strings.TrimSpace(s.AttrOr("type", "")), "application/json+oembed") {
+			return true
+		}
+		candidate := ac.resolveURL(strings.TrimSpace(s.AttrOr("href", "")), baseURL)
+		if !allowedOEmbedEndpoint(candidate, pageURL) {
+			ac.logger.Debugw("Ignoring oEmbed endpoint outside the page's host", "endpoint", candidate)
+			return true
+		}
+		endpoint = candidate
+		return false
+	})
+	return endpoint
+}
+
+// allowedOEmbedEndpoint reports whether a discovered endpoint is an http(s) URL on the page's
+// host, ignoring a www. prefix, or on the host of a registered provider
+func allowedOEmbedEndpoint(endpoint string, pageURL *url.URL) bool {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || !isHTTPURL(parsed) {
+		return false
+	}
+	if pageURL != nil && sameHost(parsed, pageURL) {
+		return true
+	}
+	for _, provider := range oembedProviders {
+		if providerURL, err := url.Parse(provider.Endpoint); err == nil && sameHost(parsed, providerURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameHost reports whether two URLs have the same host and port, ignoring a www. prefix
+func sameHost(a, b *url.URL) bool {
+	return strings.TrimPrefix(strings.ToLower(a.Host), "www.") == strings.TrimPrefix(strings.ToLower(b.Host), "www.")
+}
+
+// lookupOEmbedEndpoint builds an oEmbed request URL from the built-in provider registry
+func lookupOEmbedEndpoint(pageURL *url.URL) string {
+	if pageURL == nil {
+		return ""
+	}
+
+	host := strings.ToLower(pageURL.Hostname())
+	for _, provider := range oembedProviders {
+		for _, providerHost := range provider.Hosts {
+			if host != providerHost && !strings.HasSuffix(host, "."+providerHost) {
+				continue
+			}
+
+			endpoint, err := url.Parse(provider.Endpoint)
+			if err != nil {
+				return ""
+			}
+			query := endpoint.Query()
+			query.Set("url", pageURL.String())
+			query.Set("format", "json")
+			endpoint.RawQuery = query.Encode()
+			return endpoint.String()
+		}
+	}
+
+	return ""
+}
+
+// fetchOEmbed requests and decodes an oEmbed JSON response
+func (ac *ArticleCleaner) fetchOEmbed(endpoint string) (*OEmbedData, error) {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := oembedClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected oEmbed status code %d", resp.StatusCode)
+	}
+
+	var payload oembedResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOEmbedResponseSize)).Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	return &OEmbedData{
+		Type:            payload.Type,
+		Title:           payload.Title,
+		AuthorName:      payload.AuthorName,
+		AuthorURL:       payload.AuthorURL,
+		ProviderName:    payload.ProviderName,
+		ProviderURL:     payload.ProviderURL,
+		HTML:            payload.HTML,
+		Width:           int(payload.Width),
+		Height:          int(payload.Height),
+		ThumbnailURL:    payload.ThumbnailURL,
+		ThumbnailWidth:  int(payload.ThumbnailWidth),
+		ThumbnailHeight: int(payload.ThumbnailHeight),
+		Endpoint:        endpoint,
+	}, nil
+}
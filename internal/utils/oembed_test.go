@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestExtractOpenGraphDataDiscoversOEmbed(t *testing.T) {
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/oembed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"type": "video",
+			"version": "1.0",
+			"title": "A Video",
+			"author_name": "Jane Doe",
+			"provider_name": "Local Video",
+			"html": "<iframe src=\"https://player.example.com/1\"></iframe>",
+			"width": 640,
+			"height": "360",
+			"thumbnail_url": "/thumb.jpg",
+			"thumbnail_width": 480,
+			"thumbnail_height": 270
+		}`))
+	})
+	mux.HandleFunc("/watch", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>
+			<title>A Video</title>
+			<link rel="alternate" type="application/json+oembed" href="/oembed?url=watch" />
+		</head><body><p>Video</p></body></html>`))
+	})
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	og, err := ac.ExtractOpenGraphData(ts.URL + "/watch")
+	if err != nil {
+		t.Fatalf("ExtractOpenGraphData failed: %v", err)
+	}
+	if og.OEmbed == nil {
+		t.Fatal("Expected oEmbed data to be present")
+	}
+
+	oembed := og.OEmbed
+	if oembed.AuthorName != "Jane Doe" {
+		t.Errorf("Expected author_name 'Jane Doe', got %q", oembed.AuthorName)
+	}
+	if oembed.HTML == "" {
+		t.Error("Expected oEmbed html to be present")
+	}
+	if oembed.Width != 640 || oembed.Height != 360 {
+		t.Errorf("Expected 640x360, got %dx%d", oembed.Width, oembed.Height)
+	}
+	if oembed.ThumbnailURL != ts.URL+"/thumb.jpg" {
+		t.Errorf("Expected resolved thumbnail URL, got %q", oembed.ThumbnailURL)
+	}
+	if og.Image != oembed.ThumbnailURL {
+		t.Errorf("Expected og image to fall back to oEmbed thumbnail, got %q", og.Image)
+	}
+}
+
+func TestExtractOpenGraphDataUsesOEmbedProviderRegistry(t *testing.T) {
+	var requestedURL string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedURL = r.URL.Query().Get("url")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type": "rich", "title": "Track", "author_name": "Artist", "html": "<iframe></iframe>", "width": "100%"}`))
+	}))
+	defer provider.Close()
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Track</title></head><body><p>Track</p></body></html>`))
+	}))
+	defer page.Close()
+
+	pageURL, _ := url.Parse(page.URL)
+	originalProviders := oembedProviders
+	oembedProviders = []oembedProvider{{Name: "Local", Hosts: []string{pageURL.Hostname()}, Endpoint: provider.URL + "/oembed"}}
+	defer func() { oembedProviders = originalProviders }()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	og, err := ac.ExtractOpenGraphData(page.URL + "/track/1")
+	if err != nil {
+		t.Fatalf("ExtractOpenGraphData failed: %v", err)
+	}
+	if og.OEmbed == nil {
+		t.Fatal("Expected oEmbed data from provider registry")
+	}
+	if requestedURL != page.URL+"/track/1" {
+		t.Errorf("Expected provider to receive page URL, got %q", requestedURL)
+	}
+	if og.OEmbed.AuthorName != "Artist" {
+		t.Errorf("Expected author_name 'Artist', got %q", og.OEmbed.AuthorName)
+	}
+	if og.OEmbed.Width != 0 {
+		t.Errorf("Expected non-numeric width to be ignored, got %d", og.OEmbed.Width)
+	}
+}
+
+func TestExtractOpenGraphDataFetchesOEmbedForCompleteMetadata(t *testing.T) {
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/oembed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type": "video", "title": "A Video", "author_name": "Jane Doe", "html": "<iframe></iframe>", "thumbnail_url": "/thumb.jpg"}`))
+	})
+	mux.HandleFunc("/watch", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>
+			<meta property="og:title" content="A Video" />
+			<meta property="og:description" content="A video about videos" />
+			<meta property="og:image" content="/poster.jpg" />
+			<link rel="alternate" type="application/json+oembed" href="/oembed?url=watch" />
+		</head><body><p>Video</p></body></html>`))
+	})
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	og, err := ac.ExtractOpenGraphData(ts.URL + "/watch")
+	if err != nil {
+		t.Fatalf("ExtractOpenGraphData failed: %v", err)
+	}
+	if og.OEmbed == nil || og.OEmbed.HTML == "" || og.OEmbed.AuthorName != "Jane Doe" {
+		t.Fatalf("Expected oEmbed data alongside complete Open Graph metadata, got %+v", og.OEmbed)
+	}
+	if og.Image != ts.URL+"/poster.jpg" {
+		t.Errorf("Expected og:image to be kept over the oEmbed thumbnail, got %q", og.Image)
+	}
+}
+
+func TestExtractOpenGraphDataIgnoresForeignOEmbedEndpoint(t *testing.T) {
+	requested := false
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type": "video", "title": "A Video"}`))
+	}))
+	defer other.Close()
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>
+			<title>A Video</title>
+			<link rel="alternate" type="application/json+oembed" href="` + other.URL + `/oembed" />
+		</head><body><p>Video</p></body></html>`))
+	}))
+	defer page.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	og, err := ac.ExtractOpenGraphData(page.URL + "/watch")
+	if err != nil {
+		t.Fatalf("ExtractOpenGraphData failed: %v", err)
+	}
+	if requested || og.OEmbed != nil {
+		t.Errorf("Expected no request to an oEmbed endpoint on another host, got %+v", og.OEmbed)
+	}
+}
+
+func TestAllowedOEmbedEndpoint(t *testing.T) {
+	pageURL, _ := url.Parse("https://www.example.com/watch/1")
+
+	tests := []struct {
+		endpoint string
+		want     bool
+	}{
+		{"https://www.example.com/oembed?url=1", true},
+		{"http://example.com/oembed", true},
+		{"https://www.youtube.com/oembed?url=1", true},
+		{"https://example.com:8080/oembed", false},
+		{"https://internal.example.net/oembed", false},
+		{"ftp://www.example.com/oembed", false},
+		{"javascript:alert(1)", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := allowedOEmbedEndpoint(tt.endpoint, pageURL); got != tt.want {
+			t.Errorf("allowedOEmbedEndpoint(%q) = %v, want %v", tt.endpoint, got, tt.want)
+		}
+	}
+}
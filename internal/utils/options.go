@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ArticleOptions controls optional processing steps of CleanArticleWithOptions
+type ArticleOptions struct {
+	// ImageFormats lists image formats in order of preference (e.g. "jpeg", "webp", "avif")
+	ImageFormats []string
+	// TrackingParams are query parameter rules removed from links; a trailing * matches a prefix
+	TrackingParams []string
+	// RewriteLinks removes tracking parameters from the links in Content and Markdown as well
+	RewriteLinks bool
+	// IncludeTOC prepends a table of contents to the markdown
+	IncludeTOC bool
+	// WordsPerMinute is the reading speed used for the reading time estimate
+	WordsPerMinute int
+	// SummarySentences is the number of sentences in the extractive summary; 0 disables it
+	SummarySentences int
+	// ExcerptMode is ExcerptModeLead (default) or ExcerptModeSmart
+	ExcerptMode string
+	// ExcerptLength is the maximum excerpt length in user-perceived characters
+	ExcerptLength int
+	// KeywordCount is the number of keywords to return; 0 disables keyword extraction
+	KeywordCount int
+	// KeywordLanguage overrides the detected language used for keyword stopwords
+	KeywordLanguage string
+	// KeywordStopwords are extra words never used as keywords
+	KeywordStopwords []string
+	// Markdown controls the markdown flavor and rendering rules
+	Markdown MarkdownOptions
+	// Chunking splits the markdown into Chunks when set
+	Chunking *ChunkOptions
+}
+
+// DefaultArticleOptions returns the options used by CleanArticle
+func DefaultArticleOptions() ArticleOptions {
+	return ArticleOptions{
+		ImageFormats:   slices.Clone(defaultImageFormats),
+		TrackingParams: slices.Clone(defaultTrackingParams),
+		WordsPerMinute: defaultWordsPerMinute,
+		ExcerptMode:    ExcerptModeLead,
+		ExcerptLength:  defaultExcerptLength,
+		Markdown:       DefaultMarkdownOptions(),
+	}
+}
+
+// Validate reports the first option CleanArticleWithOptions does not support
+func (o ArticleOptions) Validate() error {
+	if o.ExcerptMode != "" && o.ExcerptMode != ExcerptModeLead && o.ExcerptMode != ExcerptModeSmart {
+		return fmt.Errorf("unsupported excerpt mode %q", o.ExcerptMode)
+	}
+	return o.Markdown.Validate()
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PageLinks represents the document-level links declared in the page head
+type PageLinks struct {
+	Canonical  string          `json:"canonical,omitempty"`
+	AMP        string          `json:"amp,omitempty"`
+	Alternates []AlternateLink `json:"alternates,omitempty"`
+	Feeds      []FeedLink      `json:"feeds,omitempty"`
+	Next       string          `json:"next,omitempty"`
+	Prev       string          `json:"prev,omitempty"`
+}
+
+// AlternateLink represents an alternate-language version of the page
+type AlternateLink struct {
+	Lang string `json:"hreflang"`
+	URL  string `json:"url"`
+}
+
+// FeedLink represents an RSS, Atom or JSON feed advertised by the page
+type FeedLink struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+	Title  string `json:"title,omitempty"`
+}
+
+// feedFormats maps feed MIME types to a short format name
+var feedFormats = map[string]string{
+	"application/rss+xml":   "rss",
+	"application/atom+xml":  "atom",
+	"application/feed+json": "json",
+	"application/json+feed": "json",
+}
+
+// extractPageLinks extracts canonical, AMP, alternate-language, feed and pagination links
+func (ac *ArticleCleaner) extractPageLinks(doc *goquery.Document, baseURL *url.URL) *PageLinks {
+	links := &PageLinks{}
+	seenAlternates := make(map[string]bool)
+	seenFeeds := make(map[string]bool)
+
+	doc.Find("link[rel][href]").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		if href == "" {
+			return
+		}
+		resolvedURL := ac.resolveURL(href, baseURL)
+
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			switch rel {
+			case "canonical":
+				if links.Canonical == "" {
+					links.Canonical = resolvedURL
+				}
+			case "amphtml":
+				if links.AMP == "" {
+					links.AMP = resolvedURL
+				}
+			case "next":
+				if links.Next == "" {
+					links.Next = resolvedURL
+				}
+			case "prev", "previous":
+				if links.Prev == "" {
+					links.Prev = resolvedURL
+				}
+			case "alternate":
+				if lang := strings.TrimSpace(s.AttrOr("hreflang", "")); lang != "" {
+					key := strings.ToLower(lang) + " " + resolvedURL
+					if !seenAlternates[key] {
+						seenAlternates[key] = true
+						links.Alternates = append(links.Alternates, AlternateLink{Lang: lang, URL: resolvedURL})
+					}
+					continue
+				}
+
+				mimeType := strings.ToLower(strings.TrimSpace(s.AttrOr("type", "")))
+				if format, ok := feedFormats[mimeType]; ok && !seenFeeds[resolvedURL] {
+					seenFeeds[resolvedURL] = true
+					links.Feeds = append(links.Feeds, FeedLink{
+						URL:    resolvedURL,
+						Format: format,
+						Title:  strings.TrimSpace(s.AttrOr("title", "")),
+					})
+				}
+			}
+		}
+	})
+
+	if links.Canonical == "" && links.AMP == "" && links.Next == "" && links.Prev == "" &&
+		len(links.Alternates) == 0 && len(links.Feeds) == 0 {
+		return nil
+	}
+
+	ac.logger.Debugw("Extracted page links",
+		"canonical", links.Canonical,
+		"amp", links.AMP,
+		"alternates", len(links.Alternates),
+		"feeds", len(links.Feeds),
+	)
+
+	return links
+}
+
+// canonicalArticleURL returns the canonical URL for an article, falling back to the requested URL
+// when the canonical is missing, not an absolute http(s) URL, or the site root of a page that
+// has a path
+func canonicalArticleURL(og *OpenGraphData, pageURL string) string {
+	if og == nil || og.Links == nil || og.Links.Canonical == "" {
+		return pageURL
+	}
+
+	canonical, err := url.Parse(og.Links.Canonical)
+	if err != nil || !isHTTPURL(canonical) {
+		return pageURL
+	}
+	if page, err := url.Parse(pageURL); err == nil && isSiteRoot(canonical) && strings.Trim(page.Path, "/") != "" {
+		return pageURL
+	}
+	return og.Links.Canonical
+}
+
+// isSiteRoot reports whether a URL points at the homepage of its site
+func isSiteRoot(parsed *url.URL) bool {
+	return strings.Trim(parsed.Path, "/") == "" && parsed.RawQuery == ""
+}
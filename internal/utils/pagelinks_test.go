@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExtractPageLinks(t *testing.T) {
+	html := `<html>
+<head>
+	<title>Links</title>
+	<link rel="canonical" href="/blog/post" />
+	<link rel="amphtml" href="https://example.com/amp/blog/post" />
+	<link rel="alternate" hreflang="de" href="https://example.com/de/blog/post" />
+	<link rel="alternate" hreflang="x-default" href="https://example.com/blog/post" />
+	<link rel="alternate" type="application/rss+xml" title="RSS" href="/feed.xml" />
+	<link rel="alternate" type="application/atom+xml" href="/atom.xml" />
+	<link rel="alternate" type="application/feed+json" href="/feed.json" />
+	<link rel="alternate" type="application/json+oembed" href="/oembed?url=x" />
+	<link rel="next" href="/blog/post?page=2" />
+	<link rel="prev" href="/blog/post?page=0" />
+</head>
+<body><p>Content</p></body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(html))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	og, err := ac.ExtractOpenGraphData(ts.URL + "/blog/post?utm_source=rss")
+	if err != nil {
+		t.Fatalf("ExtractOpenGraphData failed: %v", err)
+	}
+	if og.Links == nil {
+		t.Fatal("Expected links to be extracted")
+	}
+
+	links := og.Links
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"Canonical", links.Canonical, ts.URL + "/blog/post"},
+		{"AMP", links.AMP, "https://example.com/amp/blog/post"},
+		{"Next", links.Next, ts.URL + "/blog/post?page=2"},
+		{"Prev", links.Prev, ts.URL + "/blog/post?page=0"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if len(links.Alternates) != 2 || links.Alternates[0].Lang != "de" {
+		t.Errorf("Expected 2 alternates starting with de, got %+v", links.Alternates)
+	}
+
+	var formats []string
+	for _, feed := range links.Feeds {
+		formats = append(formats, feed.Format)
+	}
+	if got := strings.Join(formats, ","); got != "rss,atom,json" {
+		t.Errorf("Expected feeds rss,atom,json, got %s", got)
+	}
+	if links.Feeds[0].Title != "RSS" {
+		t.Errorf("Expected feed title 'RSS', got %q", links.Feeds[0].Title)
+	}
+}
+
+func TestCanonicalArticleURL(t *testing.T) {
+	pageURL := "https://example.com/2024/01/story"
+
+	tests := []struct {
+		name      string
+		canonical string
+		want      string
+	}{
+		{"canonical used", "https://example.com/story", "https://example.com/story"},
+		{"no canonical", "", pageURL},
+		{"non-http scheme", "javascript:alert(1)", pageURL},
+		{"hostless", "https:///story", pageURL},
+		{"malformed", "https://exa mple.com/%zz", pageURL},
+		{"site root", "https://example.com/", pageURL},
+		{"site root without slash", "https://www.example.com", pageURL},
+	}
+
+	for _, tt := range tests {
+		og := &OpenGraphData{Links: &PageLinks{Canonical: tt.canonical}}
+		if got := canonicalArticleURL(og, pageURL); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := canonicalArticleURL(&OpenGraphData{Links: &PageLinks{Canonical: "https://example.com/"}}, "https://example.com/?ref=home"); got != "https://example.com/" {
+		t.Errorf("Expected a site root canonical for a homepage, got %q", got)
+	}
+}
+
+func TestCleanArticleUsesCanonicalURL(t *testing.T) {
+	html := `<html>
+<head>
+	<title>Canonical</title>
+	<link rel="canonical" href="https://example.com/canonical-article" />
+</head>
+<body>
+	<article>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+	</article>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(html))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL + "/article?utm_source=twitter&fbclid=abc")
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+	if article.URL != "https://example.com/canonical-article" {
+		t.Errorf("Expected canonical URL, got %q", article.URL)
+	}
+}
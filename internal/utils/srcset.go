@@ -0,0 +1,352 @@
+package utils
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ImageCandidate represents one image source offered through src, srcset or <source>
+type ImageCandidate struct {
+	URL     string  `json:"url"`
+	Width   int     `json:"width,omitempty"`
+	Density float64 `json:"density,omitempty"`
+	Type    string  `json:"type,omitempty"`
+	Media   string  `json:"media,omitempty"`
+}
+
+// imageSelection records the candidate chosen for an image and the alternatives passed over
+type imageSelection struct {
+	chosen       ImageCandidate
+	alternatives []ImageCandidate
+}
+
+// defaultImageFormats favours formats every markdown renderer can display
+var defaultImageFormats = []string{"jpeg", "png", "gif", "webp", "avif"}
+
+// densityReferenceWidth approximates the width of a 1x candidate when no width hint is known
+const densityReferenceWidth = 1000
+
+// sizesViewportWidth is the viewport width, in CSS pixels, that sizes media conditions and vw
+// lengths are evaluated against
+const sizesViewportWidth = 1280
+
+// cssFontSize is the font size, in CSS pixels, em and rem lengths are evaluated against
+const cssFontSize = 16
+
+// sizesConditionPattern matches the min-width and max-width features of a sizes media condition
+var sizesConditionPattern = regexp.MustCompile(`\(\s*(min|max)-width\s*:\s*([0-9.]+)(px|r?em)\s*\)`)
+
+// comparableWidthRatio is the share of the largest candidate width a candidate needs for its
+// format to be considered, so a preferred format never wins with a much smaller image
+const comparableWidthRatio = 0.75
+
+// imageFormatAliases maps MIME subtypes and file extensions to format names
+var imageFormatAliases = map[string]string{
+	"jpeg": "jpeg", "jpg": "jpeg", "pjpeg": "jpeg",
+	"png": "png", "apng": "png",
+	"gif":  "gif",
+	"webp": "webp",
+	"avif": "avif",
+	"svg":  "svg", "svg+xml": "svg",
+	"jxl": "jxl",
+}
+
+// parseSrcset parses a srcset attribute following the HTML candidate string rules, so URLs that
+// contain commas (common with image CDNs) are kept intact
+func parseSrcset(srcset string) []ImageCandidate {
+	var candidates []ImageCandidate
+
+	input := srcset
+	for {
+		// Skip whitespace and stray commas between candidates
+		input = strings.TrimLeft(input, " \t\n\r\f,")
+		if input == "" {
+			break
+		}
+
+		// The URL is a run of non-whitespace characters
+		end := strings.IndexAny(input, " \t\n\r\f")
+		if end < 0 {
+			end = len(input)
+		}
+		rawURL := input[:end]
+		input = input[end:]
+
+		// A URL ending in a comma has no descriptors
+		descriptors := ""
+		if strings.HasSuffix(rawURL, ",") {
+			rawURL = strings.TrimRight(rawURL, ",")
+		} else {
+			descriptors, input = splitSrcsetDescriptors(input)
+		}
+		if rawURL == "" {
+			continue
+		}
+
+		candidate := ImageCandidate{URL: rawURL}
+		valid := true
+		for _, descriptor := range strings.Fields(descriptors) {
+			value := descriptor[:len(descriptor)-1]
+			switch descriptor[len(descriptor)-1] {
+			case 'w':
+				width, err := strconv.Atoi(value)
+				if err != nil || width <= 0 || candidate.Width != 0 || candidate.Density != 0 {
+					valid = false
+				}
+				candidate.Width = width
+			case 'x':
+				density, err := strconv.ParseFloat(value, 64)
+				if err != nil || density <= 0 || candidate.Width != 0 || candidate.Density != 0 {
+					valid = false
+				}
+				candidate.Density = density
+			case 'h':
+				// Height descriptors are only meaningful alongside a width; ignore them
+			default:
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+		if candidate.Width == 0 && candidate.Density == 0 {
+			candidate.Density = 1
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+// splitSrcsetDescriptors reads descriptors up to the next comma that is not inside parentheses
+func splitSrcsetDescriptors(input string) (string, string) {
+	depth := 0
+	for i, r := range input {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				return input[:i], input[i+1:]
+			}
+		}
+	}
+	return input, ""
+}
+
+// parseSizes returns the slot width in CSS pixels given by a sizes attribute: the length of the
+// first entry whose media condition matches the viewport, or of the default entry. Conditions
+// and lengths that cannot be evaluated, such as calc() or auto, are skipped; 0 means unknown.
+func parseSizes(sizes string) int {
+	for _, entry := range splitSizes(sizes) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasSuffix(entry, ")") {
+			continue
+		}
+
+		// The length is the last token, anything before it the media condition
+		condition, length := "", entry
+		if i := strings.LastIndexAny(entry, " \t\n\r\f)"); i >= 0 {
+			condition, length = strings.TrimSpace(entry[:i+1]), entry[i+1:]
+		}
+		if condition != "" && !matchesSizesCondition(condition) {
+			continue
+		}
+		if width := cssLength(length); width > 0 {
+			return width
+		}
+	}
+	return 0
+}
+
+// splitSizes splits a sizes attribute on the commas that are not inside parentheses
+func splitSizes(sizes string) []string {
+	var entries []string
+	for sizes != "" {
+		var entry string
+		entry, sizes = splitSrcsetDescriptors(sizes)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// matchesSizesCondition reports whether a media condition made of min-width and max-width
+// features joined by "and" matches the viewport. Other conditions never match.
+func matchesSizesCondition(condition string) bool {
+	condition = strings.ToLower(condition)
+	matches := sizesConditionPattern.FindAllStringSubmatchIndex(condition, -1)
+	if len(matches) == 0 {
+		return false
+	}
+
+	// Everything outside the width features must be "and"
+	rest := condition
+	for i := len(matches) - 1; i >= 0; i-- {
+		rest = rest[:matches[i][0]] + " " + rest[matches[i][1]:]
+	}
+	for _, word := range strings.Fields(rest) {
+		if word != "and" {
+			return false
+		}
+	}
+
+	for _, match := range matches {
+		feature, unit := condition[match[2]:match[3]], condition[match[6]:match[7]]
+		value, err := strconv.ParseFloat(condition[match[4]:match[5]], 64)
+		if err != nil {
+			return false
+		}
+		if unit != "px" {
+			value *= cssFontSize
+		}
+		if (feature == "min" && sizesViewportWidth < value) || (feature == "max" && sizesViewportWidth > value) {
+			return false
+		}
+	}
+	return true
+}
+
+// cssLength converts a px, vw, em or rem length to CSS pixels, returning 0 for other values
+func cssLength(length string) int {
+	length = strings.ToLower(strings.TrimSpace(length))
+	scales := []struct {
+		unit  string
+		scale float64
+	}{{"px", 1}, {"vw", sizesViewportWidth / 100.0}, {"rem", cssFontSize}, {"em", cssFontSize}}
+	for _, s := range scales {
+		if value, ok := strings.CutSuffix(length, s.unit); ok {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil || n <= 0 {
+				return 0
+			}
+			return int(n*s.scale + 0.5)
+		}
+	}
+	return 0
+}
+
+// imageFormat returns the format of a candidate from its type attribute, file extension or a
+// format query parameter
+func imageFormat(candidate ImageCandidate) string {
+	if candidate.Type != "" {
+		subtype := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(candidate.Type), "image/"))
+		return imageFormatAliases[subtype]
+	}
+
+	parsed, err := url.Parse(candidate.URL)
+	if err != nil {
+		return ""
+	}
+	if ext := strings.TrimPrefix(strings.ToLower(path.Ext(parsed.Path)), "."); ext != "" {
+		if format, ok := imageFormatAliases[ext]; ok {
+			return format
+		}
+	}
+	query := parsed.Query()
+	for _, key := range []string{"format", "fm", "f", "output"} {
+		if format, ok := imageFormatAliases[strings.ToLower(query.Get(key))]; ok {
+			return format
+		}
+	}
+	return ""
+}
+
+// selectImageCandidate picks the largest candidate, a density candidate counting as widthHint
+// times its density wide. Format preference only chooses between candidates of comparable width
+// (at least comparableWidthRatio of the largest); among those the most preferred format wins,
+// then the larger width. Candidates limited to small screens by a
+// max-width media query are only used when nothing else is offered.
+func selectImageCandidate(candidates []ImageCandidate, formats []string, widthHint int) (ImageCandidate, bool) {
+	if len(candidates) == 0 {
+		return ImageCandidate{}, false
+	}
+	if len(formats) == 0 {
+		formats = defaultImageFormats
+	}
+	if widthHint <= 0 {
+		widthHint = densityReferenceWidth
+	}
+
+	formatRank := func(candidate ImageCandidate) int {
+		format := imageFormat(candidate)
+		for i, preferred := range formats {
+			if imageFormatAliases[strings.ToLower(preferred)] == format && format != "" {
+				return i
+			}
+		}
+		if format == "" {
+			// Unknown formats are usually CDN URLs serving something the browser accepts
+			return len(formats)
+		}
+		return len(formats) + 1
+	}
+	smallScreenOnly := func(candidate ImageCandidate) bool {
+		return strings.Contains(strings.ToLower(candidate.Media), "max-width")
+	}
+	effectiveWidth := func(candidate ImageCandidate) float64 {
+		if candidate.Width > 0 {
+			return float64(candidate.Width)
+		}
+		return candidate.Density * float64(widthHint)
+	}
+
+	pool := make([]ImageCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !smallScreenOnly(candidate) {
+			pool = append(pool, candidate)
+		}
+	}
+	if len(pool) == 0 {
+		pool = candidates
+	}
+
+	largest := 0.0
+	for _, candidate := range pool {
+		largest = max(largest, effectiveWidth(candidate))
+	}
+	ranked := make([]ImageCandidate, 0, len(pool))
+	for _, candidate := range pool {
+		if effectiveWidth(candidate) >= largest*comparableWidthRatio {
+			ranked = append(ranked, candidate)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if rankA, rankB := formatRank(a), formatRank(b); rankA != rankB {
+			return rankA < rankB
+		}
+		return effectiveWidth(a) > effectiveWidth(b)
+	})
+
+	return ranked[0], true
+}
+
+// imgCandidates returns the candidates offered by an img srcset and its src, which counts as 1x
+func imgCandidates(img *goquery.Selection) []ImageCandidate {
+	candidates := parseSrcset(img.AttrOr("srcset", ""))
+	if src := strings.TrimSpace(img.AttrOr("src", "")); !isPlaceholderImage(img) && !containsImageCandidate(candidates, src) {
+		candidates = append(candidates, ImageCandidate{URL: src, Density: 1})
+	}
+	return candidates
+}
+
+// containsImageCandidate reports whether candidates already include imageURL
+func containsImageCandidate(candidates []ImageCandidate, imageURL string) bool {
+	for _, candidate := range candidates {
+		if candidate.URL == imageURL {
+			return true
+		}
+	}
+	return false
+}
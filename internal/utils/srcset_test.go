@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		name   string
+		srcset string
+		want   []ImageCandidate
+	}{
+		{
+			name:   "width descriptors",
+			srcset: "small.jpg 400w, large.jpg 1200w",
+			want:   []ImageCandidate{{URL: "small.jpg", Width: 400}, {URL: "large.jpg", Width: 1200}},
+		},
+		{
+			name:   "density descriptors",
+			srcset: "photo.jpg, photo@2x.jpg 2x,photo@1.5x.jpg 1.5x",
+			want: []ImageCandidate{
+				{URL: "photo.jpg", Density: 1},
+				{URL: "photo@2x.jpg", Density: 2},
+				{URL: "photo@1.5x.jpg", Density: 1.5},
+			},
+		},
+		{
+			name:   "commas inside CDN URLs",
+			srcset: "https://cdn.example.com/image/upload/w_400,c_fill,f_auto/a.jpg 400w, https://cdn.example.com/image/upload/w_800,c_fill,f_auto/a.jpg 800w",
+			want: []ImageCandidate{
+				{URL: "https://cdn.example.com/image/upload/w_400,c_fill,f_auto/a.jpg", Width: 400},
+				{URL: "https://cdn.example.com/image/upload/w_800,c_fill,f_auto/a.jpg", Width: 800},
+			},
+		},
+		{
+			name:   "webp in path is left alone",
+			srcset: "/webp-guide/cover.png 1x",
+			want:   []ImageCandidate{{URL: "/webp-guide/cover.png", Density: 1}},
+		},
+		{
+			name:   "height descriptor ignored and invalid candidates skipped",
+			srcset: "a.jpg 600w 400h, b.jpg 2q, c.jpg 0w, d.jpg 300w 2x",
+			want:   []ImageCandidate{{URL: "a.jpg", Width: 600}},
+		},
+		{
+			name:   "extra whitespace and newlines",
+			srcset: "\n  one.jpg   100w ,\n  two.jpg 200w  ",
+			want:   []ImageCandidate{{URL: "one.jpg", Width: 100}, {URL: "two.jpg", Width: 200}},
+		},
+		{
+			name:   "empty",
+			srcset: "  ",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSrcset(tt.srcset); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSrcset(%q) = %+v, want %+v", tt.srcset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectImageCandidate(t *testing.T) {
+	candidates := []ImageCandidate{
+		{URL: "/img/hero-800.avif", Width: 800, Type: "image/avif"},
+		{URL: "/img/hero-1600.avif", Width: 1600, Type: "image/avif"},
+		{URL: "/img/hero-800.webp", Width: 800, Type: "image/webp"},
+		{URL: "/img/hero-1600.webp", Width: 1600, Type: "image/webp"},
+		{URL: "/img/hero-mobile.jpg", Width: 2000, Media: "(max-width: 600px)"},
+		{URL: "/img/hero.jpg", Density: 1},
+		{URL: "/img/hero@2x.jpg", Density: 2},
+	}
+
+	tests := []struct {
+		name    string
+		formats []string
+		want    string
+	}{
+		{"default prefers compatible formats", nil, "/img/hero@2x.jpg"},
+		{"webp first", []string{"webp", "jpeg"}, "/img/hero-1600.webp"},
+		{"avif first", []string{"avif", "webp"}, "/img/hero-1600.avif"},
+		{"no preferred format falls back to largest", []string{"png"}, "/img/hero@2x.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := selectImageCandidate(candidates, tt.formats, 0)
+			if !ok || got.URL != tt.want {
+				t.Errorf("selectImageCandidate() = %q, want %q", got.URL, tt.want)
+			}
+		})
+	}
+
+	cdn := []ImageCandidate{
+		{URL: "https://cdn.example.com/a?fm=webp&w=1200", Width: 1200},
+		{URL: "https://cdn.example.com/a?fm=jpg&w=1000", Width: 1000},
+	}
+	if got, _ := selectImageCandidate(cdn, []string{"jpeg"}, 0); got.URL != cdn[1].URL {
+		t.Errorf("Expected format from the fm query parameter to be honored, got %q", got.URL)
+	}
+
+	mixed := []ImageCandidate{
+		{URL: "/img/small.webp", Width: 320, Type: "image/webp"},
+		{URL: "/img/large.jpg", Width: 2048},
+	}
+	if got, _ := selectImageCandidate(mixed, []string{"webp", "jpeg"}, 0); got.URL != "/img/large.jpg" {
+		t.Errorf("Expected a much larger image to win over the preferred format, got %q", got.URL)
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	tests := []struct {
+		sizes string
+		want  int
+	}{
+		{"600px", 600},
+		{"50vw", 640},
+		{"40em", 640},
+		{"(max-width: 600px) 100vw, 720px", 720},
+		{"(min-width: 1024px) 800px, 100vw", 800},
+		{"(min-width: 1600px) 1200px, (min-width: 800px) and (max-width: 1599px) 50vw, 100vw", 640},
+		{"(min-width:60em)30rem,100vw", 480},
+		{"(orientation: portrait) 300px, 900px", 900},
+		{"calc(100vw - 2rem), 700px", 700},
+		{"(min-width: 800px) min(50vw, 600px), 100vw", 1280},
+		{"auto", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseSizes(tt.sizes); got != tt.want {
+			t.Errorf("parseSizes(%q) = %d, want %d", tt.sizes, got, tt.want)
+		}
+	}
+}
+
+func TestCleanArticleWithResponsiveImages(t *testing.T) {
+	responsiveHTML := `<html>
+<head><title>Responsive Images</title></head>
+<body>
+	<article>
+		<h1>Responsive Images</h1>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<picture>
+			<source type="image/avif" srcset="/img/hero-800.avif 800w, /img/hero-1600.avif 1600w">
+			<source type="image/webp" srcset="/img/hero-800.webp 800w, /img/hero-1600.webp 1600w">
+			<img src="/img/hero-800.jpg" srcset="/img/hero-800.jpg 800w, /img/hero-1600.jpg 1600w" sizes="100vw" alt="Hero">
+		</picture>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+		<img src="/webp-guide/diagram.png" srcset="/webp-guide/diagram.png 1x, /webp-guide/diagram@2x.png 2x" alt="Diagram">
+		<img src="/img/chart-1600.png" srcset="/img/chart-400.png 400w, /img/chart-800.png 800w" sizes="(max-width: 600px) 100vw, 400px" alt="Chart">
+		<p>And a third paragraph discussing the architecture decisions that were made during
+		the design phase of this particular system component.</p>
+	</article>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(responsiveHTML))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL)
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	// The chart src counts as 1x of its 400px slot, so the 800w candidate is larger
+	for _, want := range []string{ts.URL + "/img/hero-1600.jpg", ts.URL + "/webp-guide/diagram@2x.png", ts.URL + "/img/chart-800.png"} {
+		if !strings.Contains(article.Markdown, want) {
+			t.Errorf("Expected markdown to contain %s", want)
+		}
+	}
+	if strings.Contains(article.Markdown, "png-guide") {
+		t.Error("Expected URLs containing webp to be left intact")
+	}
+
+	if len(article.Images) != 3 {
+		t.Fatalf("Expected 3 images, got %d: %+v", len(article.Images), article.Images)
+	}
+	hero := article.Images[0]
+	if hero.URL != ts.URL+"/img/hero-1600.jpg" {
+		t.Errorf("Expected hero image URL, got %s", hero.URL)
+	}
+	if len(hero.Alternatives) != 5 {
+		t.Errorf("Expected 5 alternatives for the hero image, got %d: %+v", len(hero.Alternatives), hero.Alternatives)
+	}
+	if !containsImageCandidate(hero.Alternatives, ts.URL+"/img/hero-1600.avif") {
+		t.Error("Expected the avif source to be kept as an alternative")
+	}
+
+	// Preferring modern formats picks the avif source instead
+	article, err = ac.CleanArticleWithOptions(ts.URL, ArticleOptions{ImageFormats: []string{"avif", "webp", "jpeg"}})
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+	if !strings.Contains(article.Markdown, ts.URL+"/img/hero-1600.avif") {
+		t.Errorf("Expected markdown to use the avif source, got %s", article.Markdown)
+	}
+}
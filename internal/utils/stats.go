@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ArticleStats holds length, reading time and readability measures of the cleaned text
+type ArticleStats struct {
+	WordCount          int                `json:"word_count"`
+	CharacterCount     int                `json:"character_count"`
+	SentenceCount      int                `json:"sentence_count"`
+	ReadingTimeMinutes int                `json:"reading_time_minutes"`
+	ReadingTimeSeconds int                `json:"reading_time_seconds"`
+	WordsPerMinute     int                `json:"words_per_minute"`
+	Readability        *ReadabilityScores `json:"readability,omitempty"`
+}
+
+// ReadabilityScores are standard English readability indices
+type ReadabilityScores struct {
+	FleschReadingEase  float64 `json:"flesch_reading_ease"`
+	FleschKincaidGrade float64 `json:"flesch_kincaid_grade"`
+	GunningFog         float64 `json:"gunning_fog"`
+	SMOG               float64 `json:"smog"`
+}
+
+// defaultWordsPerMinute is a typical adult silent reading speed for non-fiction
+const defaultWordsPerMinute = 238
+
+// cjkCharactersPerWord scales the reading speed for CJK text, where each character counts as a
+// word but is read about twice as fast
+const cjkCharactersPerWord = 2
+
+// textCounts are the raw counts the statistics are derived from
+type textCounts struct {
+	words          int
+	cjkCharacters  int
+	sentences      int
+	syllables      int
+	complexWords   int
+	englishWords   int
+	characterCount int
+}
+
+// computeArticleStats measures the cleaned text. Readability indices are only computed for English.
+func computeArticleStats(text, language string, wordsPerMinute int) *ArticleStats {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = defaultWordsPerMinute
+	}
+
+	counts := countText(text)
+	stats := &ArticleStats{
+		WordCount:      counts.words + counts.cjkCharacters,
+		CharacterCount: counts.characterCount,
+		SentenceCount:  counts.sentences,
+		WordsPerMinute: wordsPerMinute,
+	}
+
+	minutes := (float64(counts.words) + float64(counts.cjkCharacters)/cjkCharactersPerWord) / float64(wordsPerMinute)
+	stats.ReadingTimeSeconds = int(math.Round(minutes * 60))
+	if stats.WordCount > 0 {
+		stats.ReadingTimeMinutes = int(math.Max(1, math.Ceil(minutes)))
+	}
+
+	if primarySubtag(language) == "en" && counts.englishWords > 0 && counts.sentences > 0 {
+		wordsPerSentence := float64(counts.englishWords) / float64(counts.sentences)
+		syllablesPerWord := float64(counts.syllables) / float64(counts.englishWords)
+		stats.Readability = &ReadabilityScores{
+			FleschReadingEase:  roundTo(206.835-1.015*wordsPerSentence-84.6*syllablesPerWord, 1),
+			FleschKincaidGrade: roundTo(0.39*wordsPerSentence+11.8*syllablesPerWord-15.59, 1),
+			GunningFog:         roundTo(0.4*(wordsPerSentence+100*float64(counts.complexWords)/float64(counts.englishWords)), 1),
+			SMOG:               roundTo(1.0430*math.Sqrt(float64(counts.complexWords)*30/float64(counts.sentences))+3.1291, 1),
+		}
+	}
+
+	return stats
+}
+
+// countText counts words, CJK characters, sentences and English syllables
+func countText(text string) textCounts {
+	var counts textCounts
+	var word []rune
+	pendingSentence := false
+
+	flushWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		counts.words++
+		pendingSentence = true
+		if isLatinWord(word) {
+			syllables := countSyllables(string(word))
+			counts.englishWords++
+			counts.syllables += syllables
+			if syllables >= 3 {
+				counts.complexWords++
+			}
+		}
+		word = word[:0]
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsSpace(r) {
+			counts.characterCount++
+		}
+
+		switch {
+		case isCJK(r):
+			flushWord()
+			counts.cjkCharacters++
+			pendingSentence = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			word = append(word, r)
+		case (r == '\'' || r == '’' || r == '-') && len(word) > 0 && i+1 < len(runes) && unicode.IsLetter(runes[i+1]):
+			// Keep contractions and hyphenated words together
+			word = append(word, r)
+		case (r == '.' || r == ',') && len(word) > 0 && unicode.IsDigit(word[len(word)-1]) && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			// Keep numbers such as 3.14 and 1,000 together
+			word = append(word, r)
+		default:
+			flushWord()
+			if isSentenceTerminator(r) && pendingSentence && (i+1 == len(runes) || !isSentenceTerminator(runes[i+1])) {
+				if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) || isCJKTerminator(r) || strings.ContainsRune("\"'”’)", runes[i+1]) {
+					counts.sentences++
+					pendingSentence = false
+				}
+			}
+		}
+	}
+	flushWord()
+	if pendingSentence {
+		counts.sentences++
+	}
+
+	return counts
+}
+
+// isCJK reports whether r is a Han, Hiragana or Katakana character, which are counted one per word
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// isSentenceTerminator reports whether r ends a sentence, including the Devanagari danda and
+// Arabic, Urdu, Armenian and Ethiopic full stops
+func isSentenceTerminator(r rune) bool {
+	switch r {
+	case '.', '!', '?', '…', '।', '॥', '؟', '۔', '։', '።':
+		return true
+	}
+	return isCJKTerminator(r)
+}
+
+// isCJKTerminator reports whether r is a full-width sentence terminator, which needs no space after it
+func isCJKTerminator(r rune) bool {
+	return r == '。' || r == '！' || r == '？' || r == '｡'
+}
+
+// isLatinWord reports whether a word is made of ASCII letters, the only words syllables are counted for
+func isLatinWord(word []rune) bool {
+	for _, r := range word {
+		if r > unicode.MaxASCII || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// countSyllables estimates the syllables of an English word from its vowel groups
+func countSyllables(word string) int {
+	word = strings.ToLower(strings.Trim(word, "'’-"))
+	if len(word) <= 3 {
+		return 1
+	}
+
+	// Silent endings
+	for _, suffix := range []string{"es", "ed"} {
+		if strings.HasSuffix(word, suffix) && !strings.HasSuffix(word, "ted") && !strings.HasSuffix(word, "ded") {
+			word = strings.TrimSuffix(word, suffix)
+			break
+		}
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		word = strings.TrimSuffix(word, "e")
+	}
+
+	syllables := 0
+	previousVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !previousVowel {
+			syllables++
+		}
+		previousVowel = vowel
+	}
+	if syllables == 0 {
+		return 1
+	}
+	return syllables
+}
+
+// roundTo rounds value to the given number of decimals
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
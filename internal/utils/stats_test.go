@@ -0,0 +1,143 @@
+package utils
+
+import "testing"
+
+func TestCountSyllables(t *testing.T) {
+	tests := []struct {
+		word string
+		want int
+	}{
+		{"cat", 1},
+		{"make", 1},
+		{"table", 2},
+		{"reading", 2},
+		{"wanted", 2},
+		{"jumped", 1},
+		{"beautiful", 3},
+		{"readability", 5},
+		{"rhythm", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := countSyllables(tt.word); got != tt.want {
+				t.Errorf("countSyllables(%q) = %d, want %d", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeArticleStats(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		language        string
+		wpm             int
+		wantWords       int
+		wantSentences   int
+		wantMinutes     int
+		wantSeconds     int
+		wantReadability bool
+	}{
+		{
+			name:            "english",
+			text:            "The cat sat on the mat. It didn't move! Was it asleep? Pi is 3.14, roughly.",
+			language:        "en-US",
+			wantWords:       16,
+			wantSentences:   4,
+			wantMinutes:     1,
+			wantSeconds:     4,
+			wantReadability: true,
+		},
+		{
+			name:          "chinese counts characters",
+			text:          "我们今天讨论分布式系统。它们很复杂！",
+			language:      "zh",
+			wantWords:     16,
+			wantSentences: 2,
+			wantMinutes:   1,
+			wantSeconds:   2,
+		},
+		{
+			name:          "japanese mixed with latin",
+			text:          "これはGoのテストです。",
+			language:      "ja",
+			wantWords:     10,
+			wantSentences: 1,
+			wantMinutes:   1,
+			wantSeconds:   1,
+		},
+		{
+			name:          "german has no readability indices",
+			text:          "Das ist ein kurzer Satz. Noch einer.",
+			language:      "de",
+			wantWords:     7,
+			wantSentences: 2,
+			wantMinutes:   1,
+			wantSeconds:   2,
+		},
+		{
+			name:          "configurable reading speed",
+			text:          "one two three four five six seven eight nine ten",
+			language:      "en",
+			wpm:           5,
+			wantWords:     10,
+			wantSentences: 1,
+			wantMinutes:   2,
+			wantSeconds:   120,
+			// A single sentence of short words still gets scores
+			wantReadability: true,
+		},
+		{
+			name:     "empty",
+			text:     "  \n ",
+			language: "en",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := computeArticleStats(tt.text, tt.language, tt.wpm)
+			if stats.WordCount != tt.wantWords {
+				t.Errorf("WordCount = %d, want %d", stats.WordCount, tt.wantWords)
+			}
+			if stats.SentenceCount != tt.wantSentences {
+				t.Errorf("SentenceCount = %d, want %d", stats.SentenceCount, tt.wantSentences)
+			}
+			if stats.ReadingTimeMinutes != tt.wantMinutes {
+				t.Errorf("ReadingTimeMinutes = %d, want %d", stats.ReadingTimeMinutes, tt.wantMinutes)
+			}
+			if stats.ReadingTimeSeconds != tt.wantSeconds {
+				t.Errorf("ReadingTimeSeconds = %d, want %d", stats.ReadingTimeSeconds, tt.wantSeconds)
+			}
+			if (stats.Readability != nil) != tt.wantReadability {
+				t.Errorf("Readability = %+v, want present: %v", stats.Readability, tt.wantReadability)
+			}
+		})
+	}
+}
+
+func TestReadabilityScores(t *testing.T) {
+	simple := computeArticleStats("The cat sat on the mat. The dog ran to the park. We had fun.", "en", 0).Readability
+	complexText := computeArticleStats("Organizational interoperability necessitates comprehensive standardization "+
+		"of institutional communication methodologies across heterogeneous administrative environments.", "en", 0).Readability
+
+	if simple == nil || complexText == nil {
+		t.Fatal("Expected readability scores for English text")
+	}
+	if simple.FleschReadingEase <= complexText.FleschReadingEase {
+		t.Errorf("Expected simple text to be easier to read: %v vs %v", simple.FleschReadingEase, complexText.FleschReadingEase)
+	}
+	for name, pair := range map[string][2]float64{
+		"Flesch-Kincaid": {simple.FleschKincaidGrade, complexText.FleschKincaidGrade},
+		"Gunning Fog":    {simple.GunningFog, complexText.GunningFog},
+		"SMOG":           {simple.SMOG, complexText.SMOG},
+	} {
+		if pair[0] >= pair[1] {
+			t.Errorf("Expected %s grade of simple text (%v) to be below complex text (%v)", name, pair[0], pair[1])
+		}
+	}
+	if simple.GunningFog > 3 {
+		t.Errorf("Expected a low Gunning Fog index for simple text, got %v", simple.GunningFog)
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import "strings"
+
+// stopwords lists function words ignored when comparing and scoring text, by primary language subtag
+var stopwords = map[string]map[string]bool{
+	"en": wordSet(`a about above after again against all am an and any are as at be because been
+		before being below between both but by can could did do does doing down during each few for
+		from further had has have having he her here hers herself him himself his how i if in into is
+		it its itself just me more most my myself no nor not now of off on once only or other our ours
+		ourselves out over own same she should so some such than that the their theirs them themselves
+		then there these they this those through to too under until up very was we were what when where
+		which while who whom why will with would you your yours yourself yourselves also said says
+		one two new like get got may might must shall us`),
+	"de": wordSet(`aber alle allem allen aller alles als also am an ander andere anderen auch auf aus
+		bei bin bis bist da damit dann das dass dein deine dem den der des dich die dies diese diesem
+		diesen dieser dieses dir doch dort du durch ein eine einem einen einer eines er es etwas euch
+		euer für gegen gewesen hab habe haben hat hatte hier hin hinter ich ihm ihn ihnen ihr ihre im
+		in ist ja jede jedem jeden jeder jedes jetzt kann kein keine können man manche mein meine mit
+		muss nach nicht nichts noch nun nur ob oder ohne sehr sein seine sich sie sind so solche soll
+		sondern sonst über um und uns unser unter viel vom von vor war waren was weil welche wenn wer
+		werden wie wieder will wir wird wo wurde zu zum zur zwar zwischen`),
+	"es": wordSet(`a al algo algunos ante antes como con contra cual cuando de del desde donde dos el
+		ella ellas ellos en entre era eran es esa esas ese eso esos esta estaba estado estas este esto
+		estos está están fue fueron ha había han hasta hay la las le les lo los más me mi mientras muy
+		nada ni no nos nosotros o os otra otros para pero poco por porque que quien se sea ser si sido
+		sin sobre su sus también tanto te tiene tienen todo todos tu un una uno unos y ya yo`),
+	"fr": wordSet(`a au aucun aussi autre aux avec avoir avait bien ce cela ces cet cette ci comme dans
+		de des deux donc du elle elles en encore est et été être eu fait il ils je la le les leur leurs
+		lui ma mais me même mes moi mon ne ni nos notre nous on ont ou où par pas peu peut plus pour
+		qu que quel quelle qui sa sans se sera ses si son sont sous sur ta te tes toi ton tous tout
+		toute très tu un une vos votre vous y`),
+	"it": wordSet(`a ad al alla alle allo anche ancora che chi ci come con cosa da dal dalla dei del
+		della delle dello di dove e ed era erano essere è fa gli ha hanno il in io la le lei li lo loro
+		lui ma mi mio molto ne nel nella nelle noi non o per perché più poi può quale quando quella
+		quelle quello questa queste questo se sei si sia sono su sua sue suo sul sulla tra tu tutti
+		tutto un una uno vi voi`),
+	"nl": wordSet(`aan al alle als bij dan dat de der deze die dit doch doen door dus een en er geen
+		had heb hebben heeft het hier hij hoe hun ik in is ja je kan kon maar me meer men met mij mijn
+		na naar niet niets nog nu of om omdat ons ook op over te tegen toch toen tot u uit van veel
+		voor want was wat we wel werd wie wij wil worden zal ze zei zelf zich zij zijn zo zonder zou`),
+	"pt": wordSet(`a ao aos as até com como da das de dela dele deles depois do dos e ela elas ele eles
+		em entre era eram essa esse esta este está estão eu foi foram há isso isto já lhe mais mas me
+		mesmo meu minha muito na nas nem no nos nossa nosso não num numa o os ou para pela pelas pelo
+		pelos por quando que quem se sem ser seu seus sua suas são só também te tem têm um uma você`),
+}
+
+// wordSet builds a lookup set from whitespace-separated words
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// isStopword reports whether the lowercase word is a stopword in the language
+func isStopword(language, word string) bool {
+	return stopwords[primarySubtag(language)][word]
+}
@@ -0,0 +1,306 @@
+package utils
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Excerpt modes
+const (
+	// ExcerptModeLead truncates the beginning of the article text
+	ExcerptModeLead = "lead"
+	// ExcerptModeSmart prefers the page description, then the first paragraph that is not a byline or caption
+	ExcerptModeSmart = "smart"
+)
+
+// TextRank settings
+const (
+	textRankDamping    = 0.85
+	textRankIterations = 50
+	textRankTolerance  = 1e-4
+	// textRankMaxSentences caps the sentences ranked, since comparing them is quadratic; only the
+	// first ones are candidates on very long pages
+	textRankMaxSentences = 400
+)
+
+// summaryMinWords is the minimum length of a sentence or paragraph considered for summaries and excerpts
+const summaryMinWords = 5
+
+// bylineMaxLength is the longest paragraph that may be treated as a byline or caption
+const bylineMaxLength = 200
+
+// paragraphBreakPattern matches the blank lines separating paragraphs in the text content
+var paragraphBreakPattern = regexp.MustCompile(`\n\s*\n`)
+
+// closingPunctuation may follow a sentence terminator within the sentence
+const closingPunctuation = "\"'”’)]»」』）"
+
+// sentenceAbbreviations end with a period without ending the sentence
+var sentenceAbbreviations = wordSet(`mr mrs ms dr prof st sr jr vs etc e.g i.e inc ltd co no fig gen
+	gov sen rep u.s u.k jan feb mar apr jun jul aug sep sept oct nov dec`)
+
+// bylinePatterns match paragraphs that are bylines, photo credits or captions rather than text
+var bylinePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^(by|von|par|por|door|di)\s+\p{Lu}[^.!?]{0,80}$`),
+	regexp.MustCompile(`(?i)^(photo|image|picture|illustration|video|graphic|credit|source)s?\s*(:|by\b)`),
+	regexp.MustCompile(`(?i)(getty images|shutterstock|associated press|ap photo|reuters/|afp via|©|\(c\) \d{4})`),
+	regexp.MustCompile(`(?i)^(published|updated|posted|last modified)\b`),
+}
+
+// datelinePattern matches a leading news dateline such as "WASHINGTON (AP) — "
+var datelinePattern = regexp.MustCompile(`^\p{Lu}{2,}[\p{Lu}\s.,]*(\s*\([^)]*\))?\s+[—–]\s+`)
+
+// summarizeText returns the count highest-ranked sentences of the text by TextRank, in their
+// original order
+func summarizeText(text, language string, count int) []string {
+	if count <= 0 {
+		return nil
+	}
+
+	var sentences []string
+	var terms [][]string
+	for _, sentence := range splitSentences(text) {
+		if counts := countText(sentence); counts.words+counts.cjkCharacters < summaryMinWords {
+			continue
+		}
+		sentences = append(sentences, sentence)
+		terms = append(terms, sentenceTerms(sentence, language))
+		if len(sentences) == textRankMaxSentences {
+			break
+		}
+	}
+	if len(sentences) <= count {
+		return sentences
+	}
+
+	scores := textRank(terms)
+	order := make([]int, len(sentences))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	selected := order[:count]
+	sort.Ints(selected)
+	summary := make([]string, 0, count)
+	for _, i := range selected {
+		summary = append(summary, sentences[i])
+	}
+	return summary
+}
+
+// textRankEdge links a sentence to a similar one
+type textRankEdge struct {
+	to     int
+	weight float64
+}
+
+// textRank scores sentences by PageRank over their pairwise term overlap, keeping only the pairs
+// that share terms
+func textRank(terms [][]string) []float64 {
+	n := len(terms)
+	edges := make([][]textRankEdge, n)
+	totals := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			similarity := sentenceSimilarity(terms[i], terms[j])
+			if similarity <= 0 {
+				continue
+			}
+			edges[i] = append(edges[i], textRankEdge{to: j, weight: similarity})
+			edges[j] = append(edges[j], textRankEdge{to: i, weight: similarity})
+			totals[i] += similarity
+			totals[j] += similarity
+		}
+	}
+
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1
+	}
+	for iteration := 0; iteration < textRankIterations; iteration++ {
+		delta := 0.0
+		next := make([]float64, n)
+		for i := 0; i < n; i++ {
+			rank := 0.0
+			for _, edge := range edges[i] {
+				rank += edge.weight / totals[edge.to] * scores[edge.to]
+			}
+			next[i] = 1 - textRankDamping + textRankDamping*rank
+			delta += math.Abs(next[i] - scores[i])
+		}
+		scores = next
+		if delta < textRankTolerance {
+			break
+		}
+	}
+	return scores
+}
+
+// sentenceSimilarity is the TextRank similarity: shared terms normalized by the log of the sentence lengths
+func sentenceSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, term := range a {
+		set[term] = true
+	}
+	shared := 0
+	for _, term := range b {
+		if set[term] {
+			shared++
+			delete(set, term)
+		}
+	}
+	if shared == 0 {
+		return 0
+	}
+
+	norm := math.Log(float64(len(a))) + math.Log(float64(len(b)))
+	if norm <= 0 {
+		norm = 1
+	}
+	return float64(shared) / norm
+}
+
+// sentenceTerms returns the distinct lowercase content words of a sentence; CJK characters are
+// terms of their own
+func sentenceTerms(sentence, language string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if term == "" || seen[term] || isStopword(language, term) {
+			return
+		}
+		if !isCJK([]rune(term)[0]) && len([]rune(term)) < 2 {
+			return
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+
+	var word strings.Builder
+	for _, r := range strings.ToLower(sentence) {
+		switch {
+		case isCJK(r):
+			add(word.String())
+			word.Reset()
+			add(string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			word.WriteRune(r)
+		default:
+			add(word.String())
+			word.Reset()
+		}
+	}
+	add(word.String())
+	return terms
+}
+
+// splitSentences splits text into sentences at terminators followed by a space or the end of
+// the paragraph, without breaking after common abbreviations and initials
+func splitSentences(text string) []string {
+	var sentences []string
+	add := func(runes []rune) {
+		if sentence := strings.TrimSpace(string(runes)); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+	}
+
+	for _, paragraph := range paragraphBreakPattern.Split(text, -1) {
+		runes := []rune(strings.Join(strings.Fields(paragraph), " "))
+		start := 0
+		for i := 0; i < len(runes); i++ {
+			if !isSentenceTerminator(runes[i]) {
+				continue
+			}
+			end := i + 1
+			for end < len(runes) && (isSentenceTerminator(runes[end]) || strings.ContainsRune(closingPunctuation, runes[end])) {
+				end++
+			}
+			if !isCJKTerminator(runes[i]) && end < len(runes) && runes[end] != ' ' {
+				// e.g. 3.14 or example.com
+				i = end - 1
+				continue
+			}
+			if runes[i] == '.' && end == i+1 && isAbbreviation(runes[start:i]) {
+				continue
+			}
+			add(runes[start:end])
+			start = end
+			i = end - 1
+		}
+		add(runes[start:])
+	}
+	return sentences
+}
+
+// isAbbreviation reports whether the text before a period ends with an abbreviation or initial
+func isAbbreviation(before []rune) bool {
+	text := string(before)
+	if i := strings.LastIndexAny(text, " ("); i >= 0 {
+		text = text[i+1:]
+	}
+	word := []rune(text)
+	if len(word) == 1 && unicode.IsUpper(word[0]) {
+		return true
+	}
+	return sentenceAbbreviations[strings.ToLower(text)]
+}
+
+// smartExcerpt prefers the page description, then the first paragraph of the content that is not
+// a byline, dateline or caption, and falls back to the beginning of the text
+func (ac *ArticleCleaner) smartExcerpt(og *OpenGraphData, content, text string, maxLength int) string {
+	if og != nil {
+		description := strings.Join(strings.Fields(og.Description), " ")
+		if counts := countText(description); counts.words+counts.cjkCharacters >= summaryMinWords {
+			return ac.generateExcerpt(description, maxLength)
+		}
+	}
+
+	if doc, err := goquery.NewDocumentFromReader(strings.NewReader(content)); err == nil {
+		excerpt := ""
+		doc.Find("p").EachWithBreak(func(i int, p *goquery.Selection) bool {
+			if p.Closest("figure, figcaption, blockquote, aside, [class*='caption'], [class*='byline'], [class*='credit']").Length() > 0 {
+				return true
+			}
+			paragraph := strings.Join(strings.Fields(p.Text()), " ")
+			if isBylineOrCaption(paragraph) {
+				return true
+			}
+			paragraph = datelinePattern.ReplaceAllString(paragraph, "")
+			if counts := countText(paragraph); counts.words+counts.cjkCharacters < summaryMinWords {
+				return true
+			}
+			excerpt = paragraph
+			return false
+		})
+		if excerpt != "" {
+			return ac.generateExcerpt(excerpt, maxLength)
+		}
+	}
+
+	return ac.generateExcerpt(text, maxLength)
+}
+
+// isBylineOrCaption reports whether a short paragraph is a byline, credit or timestamp line
+func isBylineOrCaption(paragraph string) bool {
+	if utf8.RuneCountInString(paragraph) > bylineMaxLength {
+		return false
+	}
+	for _, pattern := range bylinePatterns {
+		if pattern.MatchString(paragraph) {
+			return true
+		}
+	}
+	return false
+}
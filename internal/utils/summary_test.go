@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "terminators",
+			text: "It works. Does it? Yes!",
+			want: []string{"It works.", "Does it?", "Yes!"},
+		},
+		{
+			name: "abbreviations and numbers",
+			text: "Dr. Smith paid $3.50 at example.com on Jan. 5. J. R. R. Tolkien agreed.",
+			want: []string{"Dr. Smith paid $3.50 at example.com on Jan. 5.", "J. R. R. Tolkien agreed."},
+		},
+		{
+			name: "closing quotes",
+			text: `He said "stop." Then he left.`,
+			want: []string{`He said "stop."`, "Then he left."},
+		},
+		{
+			name: "paragraphs",
+			text: "A heading\n\nFirst line\nof a paragraph.",
+			want: []string{"A heading", "First line of a paragraph."},
+		},
+		{
+			name: "japanese",
+			text: "今日は晴れです。明日は雨でしょう。",
+			want: []string{"今日は晴れです。", "明日は雨でしょう。"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeText(t *testing.T) {
+	text := `Photo by Jane Doe for the Daily Planet.
+
+Solar panels convert sunlight into electricity for homes. The cost of solar panels has dropped sharply this decade. Many homes now install solar panels to cut electricity bills. My neighbour owns a very friendly brown dog. Solar electricity from panels also reduces emissions.`
+
+	got := summarizeText(text, "en", 2)
+	want := []string{
+		"Solar panels convert sunlight into electricity for homes.",
+		"Many homes now install solar panels to cut electricity bills.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeText() = %q, want %q", got, want)
+	}
+
+	if got := summarizeText(text, "en", 0); got != nil {
+		t.Errorf("Expected no summary for 0 sentences, got %q", got)
+	}
+	if got := summarizeText("Too short. Also short.", "en", 3); len(got) != 0 {
+		t.Errorf("Expected sentences under %d words to be skipped, got %q", summaryMinWords, got)
+	}
+	if got := summarizeText(text, "en", 10); len(got) != 6 || got[0] != "Photo by Jane Doe for the Daily Planet." {
+		t.Errorf("Expected every sentence in order when asking for more than available, got %q", got)
+	}
+
+	// Only the first textRankMaxSentences sentences of a very long text are ranked
+	var long strings.Builder
+	for i := 0; i < 10*textRankMaxSentences; i++ {
+		fmt.Fprintf(&long, "Sentence number %d talks about solar panels and homes. ", i)
+	}
+	if got := summarizeText(long.String(), "en", textRankMaxSentences+1); len(got) != textRankMaxSentences {
+		t.Errorf("Expected %d ranked sentences at most, got %d", textRankMaxSentences, len(got))
+	}
+}
+
+func TestIsBylineOrCaption(t *testing.T) {
+	tests := []struct {
+		paragraph string
+		want      bool
+	}{
+		{"By Jane Doe", true},
+		{"By Jane Doe and John Roe, Staff Writers", true},
+		{"Photo: Getty Images", true},
+		{"Image by Unsplash", true},
+		{"Updated March 3, 2024 at 10:00 a.m.", true},
+		{"© 2024 Example Media", true},
+		{"By the end of the year, the project had doubled in size and hired new staff.", false},
+		{"The council met on Tuesday to discuss the new budget proposal for schools.", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.paragraph, func(t *testing.T) {
+			if got := isBylineOrCaption(tt.paragraph); got != tt.want {
+				t.Errorf("isBylineOrCaption(%q) = %v, want %v", tt.paragraph, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSmartExcerpt(t *testing.T) {
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	content := `<div>
+		<figure><img src="a.jpg"/><figcaption><p>The mayor speaks at the opening of the new bridge.</p></figcaption></figure>
+		<p>By Jane Doe</p>
+		<p>WASHINGTON (AP) — The new bridge opened to traffic on Monday after three years of work.</p>
+		<p>Officials expect thousands of commuters to use it daily.</p>
+	</div>`
+	text := "The mayor speaks at the opening of the new bridge. By Jane Doe WASHINGTON (AP) — The new bridge opened."
+
+	tests := []struct {
+		name string
+		og   *OpenGraphData
+		want string
+	}{
+		{
+			name: "description",
+			og:   &OpenGraphData{Description: "A long-awaited bridge finally opens to commuters."},
+			want: "A long-awaited bridge finally opens to commuters.",
+		},
+		{
+			name: "first body paragraph",
+			og:   &OpenGraphData{Description: "News"},
+			want: "The new bridge opened to traffic on Monday after three years of work.",
+		},
+		{
+			name: "no metadata",
+			want: "The new bridge opened to traffic on Monday after three years of work.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ac.smartExcerpt(tt.og, content, text, 200); got != tt.want {
+				t.Errorf("smartExcerpt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := ac.smartExcerpt(nil, "<p>By Jane Doe</p>", text, 200); got != text {
+		t.Errorf("Expected fallback to the text, got %q", got)
+	}
+}
+
+func TestArticleOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(options *ArticleOptions)
+		wantErr bool
+	}{
+		{name: "defaults", mutate: func(options *ArticleOptions) {}},
+		{name: "smart excerpt", mutate: func(options *ArticleOptions) { options.ExcerptMode = ExcerptModeSmart }},
+		{name: "unknown excerpt mode", mutate: func(options *ArticleOptions) { options.ExcerptMode = "smrt" }, wantErr: true},
+		{name: "invalid markdown options", mutate: func(options *ArticleOptions) { options.Markdown.LinkStyle = "footnote" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultArticleOptions()
+			tt.mutate(&options)
+			if err := options.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCleanArticleSummary(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head>
+	<title>Solar Power</title>
+	<meta name="description" content="Why solar panels are spreading to ordinary homes.">
+</head>
+<body>
+	<article>
+		<p class="byline">By Jane Doe</p>
+		<p>Solar panels convert sunlight into electricity for homes. The cost of solar panels has
+		dropped sharply this decade. Many homes now install solar panels to cut electricity bills.</p>
+		<p>My neighbour owns a very friendly brown dog. Solar electricity from panels also reduces
+		emissions. This paragraph exists to give readability enough content to work with.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	options := DefaultArticleOptions()
+	options.SummarySentences = 2
+	options.ExcerptMode = ExcerptModeSmart
+	article, err := ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+
+	if len(article.Summary) != 2 {
+		t.Fatalf("Expected a 2 sentence summary, got %q", article.Summary)
+	}
+	for _, sentence := range article.Summary {
+		if !strings.Contains(strings.ToLower(sentence), "solar") {
+			t.Errorf("Expected summary sentences about solar panels, got %q", sentence)
+		}
+	}
+	if article.Excerpt != "Why solar panels are spreading to ordinary homes." {
+		t.Errorf("Expected the meta description as excerpt, got %q", article.Excerpt)
+	}
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// TOCEntry is a heading in the article outline
+type TOCEntry struct {
+	Level    int        `json:"level"`
+	Text     string     `json:"text"`
+	Slug     string     `json:"slug"`
+	Children []TOCEntry `json:"children,omitempty"`
+}
+
+// markdownHeadingPattern matches ATX headings produced by the markdown converter
+var markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+\S`)
+
+// setextUnderlinePattern matches the line under a setext heading: = for level 1, - for level 2
+var setextUnderlinePattern = regexp.MustCompile(`^(?:=+|-+)[ \t]*$`)
+
+var (
+	// markdownInlineLinkPattern matches inline and reference links and images, keeping their text
+	markdownInlineLinkPattern = regexp.MustCompile(`!?\[((?:\\.|[^\]\\])*)\](?:\([^)]*\)|\[[^\]]*\])`)
+	// markdownInlineMarkupPattern matches backslash escapes and emphasis, strikethrough and code
+	// markers, along with inline HTML tags
+	markdownInlineMarkupPattern = regexp.MustCompile("\\\\.|[*_~`]|<[^>]+>")
+)
+
+// buildTableOfContents assigns slug ids to the headings of the article content and returns the
+// flat heading list together with the updated content
+func (ac *ArticleCleaner) buildTableOfContents(content string) ([]TOCEntry, string) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		ac.logger.Warnw("Failed to parse article content for headings", "error", err)
+		return nil, content
+	}
+
+	var headings []TOCEntry
+	used := make(map[string]bool)
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, heading *goquery.Selection) {
+		text := strings.Join(strings.Fields(heading.Text()), " ")
+		if text == "" {
+			return
+		}
+		level, _ := strconv.Atoi(goquery.NodeName(heading)[1:])
+
+		slug := uniqueSlug(slugify(text), used)
+		heading.SetAttr("id", slug)
+		headings = append(headings, TOCEntry{Level: level, Text: text, Slug: slug})
+	})
+	if len(headings) == 0 {
+		return nil, content
+	}
+
+	updated, err := doc.Find("body").Html()
+	if err != nil {
+		return headings, content
+	}
+
+	ac.logger.Debugw("Built article outline", "headings", len(headings))
+	return headings, updated
+}
+
+// nestTOCEntries turns a flat heading list into a tree where each heading holds the deeper
+// headings that follow it
+func nestTOCEntries(headings []TOCEntry) []TOCEntry {
+	entries, _ := nestTOCLevel(headings, 0, 0)
+	return entries
+}
+
+// nestTOCLevel collects the headings deeper than parentLevel starting at headings[i]
+func nestTOCLevel(headings []TOCEntry, i, parentLevel int) ([]TOCEntry, int) {
+	var entries []TOCEntry
+	for i < len(headings) && headings[i].Level > parentLevel {
+		entry := headings[i]
+		entry.Children, i = nestTOCLevel(headings, i+1, entry.Level)
+		entries = append(entries, entry)
+	}
+	return entries, i
+}
+
+// slugify builds a GitHub-style anchor from heading text
+func slugify(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+	if b.Len() == 0 {
+		return "section"
+	}
+	return b.String()
+}
+
+// uniqueSlug appends -1, -2, ... to slugs that were already used
+func uniqueSlug(slug string, used map[string]bool) string {
+	candidate := slug
+	for n := 1; used[candidate]; n++ {
+		candidate = slug + "-" + strconv.Itoa(n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// injectHeadingAnchors places an <a id> anchor before each markdown heading so the outline
+// slugs resolve in rendered markdown. Headings are matched to the outline by level and text, so
+// headings missing from the markdown, such as those inside embedded HTML tables, are skipped.
+// Headings deeper than maxLevel were rendered at maxLevel; 0 means no limit.
+func injectHeadingAnchors(markdown string, headings []TOCEntry, maxLevel int) string {
+	if len(headings) == 0 {
+		return markdown
+	}
+
+	lines := strings.Split(markdown, "\n")
+	result := make([]string, 0, len(lines)+2*len(headings))
+	next := 0
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if level, text := markdownHeading(lines, i); !inFence && level > 0 {
+			slug := slugify(markdownPlainText(text))
+			for j := next; j < len(headings); j++ {
+				if level == clampHeadingLevel(headings[j].Level, maxLevel) && slug == slugify(headings[j].Text) {
+					result = append(result, `<a id="`+headings[j].Slug+`"></a>`, "")
+					next = j + 1
+					break
+				}
+			}
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+// markdownHeading returns the level and text of the ATX or setext heading starting at lines[i],
+// or 0 when the line does not start a heading
+func markdownHeading(lines []string, i int) (int, string) {
+	if match := markdownHeadingPattern.FindStringSubmatch(lines[i]); match != nil {
+		return len(match[1]), strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(lines[i]), "#"))
+	}
+	if strings.TrimSpace(lines[i]) == "" || i+1 >= len(lines) || !setextUnderlinePattern.MatchString(lines[i+1]) {
+		return 0, ""
+	}
+	if strings.HasPrefix(lines[i+1], "=") {
+		return 1, strings.TrimSpace(lines[i])
+	}
+	return 2, strings.TrimSpace(lines[i])
+}
+
+// markdownPlainText strips links, escapes and inline markup from a line of markdown
+func markdownPlainText(text string) string {
+	text = markdownInlineLinkPattern.ReplaceAllString(text, "$1")
+	return markdownInlineMarkupPattern.ReplaceAllStringFunc(text, func(markup string) string {
+		if strings.HasPrefix(markup, `\`) {
+			return markup[1:]
+		}
+		return ""
+	})
+}
+
+// clampHeadingLevel returns the level a heading is rendered at when levels are capped at maxLevel
+func clampHeadingLevel(level, maxLevel int) int {
+	if maxLevel > 0 && level > maxLevel {
+		return maxLevel
+	}
+	return level
+}
+
+// renderTOCMarkdown renders the outline as a nested markdown list of anchor links
+func renderTOCMarkdown(entries []TOCEntry) string {
+	var b strings.Builder
+	var render func(entries []TOCEntry, depth int)
+	render = func(entries []TOCEntry, depth int) {
+		for _, entry := range entries {
+			text := strings.NewReplacer(`[`, `\[`, `]`, `\]`).Replace(entry.Text)
+			b.WriteString(strings.Repeat("  ", depth) + "- [" + text + "](#" + entry.Slug + ")\n")
+			render(entry.Children, depth+1)
+		}
+	}
+	render(entries, 0)
+	return b.String()
+}
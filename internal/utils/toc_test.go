@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Getting Started", "getting-started"},
+		{"What's new in Go 1.23?", "whats-new-in-go-123"},
+		{"snake_case and kebab-case", "snake_case-and-kebab-case"},
+		{"Café au lait", "café-au-lait"},
+		{"日本語の見出し", "日本語の見出し"},
+		{"!!!", "section"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := slugify(tt.text); got != tt.want {
+				t.Errorf("slugify(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNestTOCEntries(t *testing.T) {
+	headings := []TOCEntry{
+		{Level: 2, Text: "Intro", Slug: "intro"},
+		{Level: 4, Text: "Deep", Slug: "deep"},
+		{Level: 3, Text: "Background", Slug: "background"},
+		{Level: 2, Text: "Usage", Slug: "usage"},
+		{Level: 1, Text: "Appendix", Slug: "appendix"},
+		{Level: 3, Text: "Notes", Slug: "notes"},
+	}
+
+	want := []TOCEntry{
+		{Level: 2, Text: "Intro", Slug: "intro", Children: []TOCEntry{
+			{Level: 4, Text: "Deep", Slug: "deep"},
+			{Level: 3, Text: "Background", Slug: "background"},
+		}},
+		{Level: 2, Text: "Usage", Slug: "usage"},
+		{Level: 1, Text: "Appendix", Slug: "appendix", Children: []TOCEntry{
+			{Level: 3, Text: "Notes", Slug: "notes"},
+		}},
+	}
+
+	if got := nestTOCEntries(headings); !reflect.DeepEqual(got, want) {
+		t.Errorf("nestTOCEntries() = %+v, want %+v", got, want)
+	}
+}
+
+func TestInjectHeadingAnchors(t *testing.T) {
+	headings := []TOCEntry{
+		{Level: 2, Text: "Setup", Slug: "setup"},
+		{Level: 2, Text: "Hidden in a table", Slug: "hidden-in-a-table"},
+		{Level: 2, Text: "Using go test and snake_case", Slug: "using-go-test-and-snake_case"},
+		{Level: 3, Text: "Prices in $", Slug: "prices-in"},
+		{Level: 2, Text: "See the docs", Slug: "see-the-docs"},
+	}
+	markdown := "## Setup\n\n<table><tr><td><h2>Hidden in a table</h2></td></tr></table>\n\n" +
+		"## Using `go test` and snake\\_case\n\n### Prices in \\$\n\nSee the docs\n------------\n\n## Not in the outline"
+	want := "<a id=\"setup\"></a>\n\n## Setup\n\n<table><tr><td><h2>Hidden in a table</h2></td></tr></table>\n\n" +
+		"<a id=\"using-go-test-and-snake_case\"></a>\n\n## Using `go test` and snake\\_case\n\n" +
+		"<a id=\"prices-in\"></a>\n\n### Prices in \\$\n\n<a id=\"see-the-docs\"></a>\n\nSee the docs\n------------\n\n## Not in the outline"
+
+	if got := injectHeadingAnchors(markdown, headings, 0); got != want {
+		t.Errorf("injectHeadingAnchors() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCleanArticleTableOfContents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head><title>A Long Guide</title></head>
+<body>
+	<article>
+		<h2>Installation</h2>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<h3>Requirements</h3>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+		<pre><code># not a heading
+echo "still code"</code></pre>
+		<h2>Configuration</h2>
+		<p>And a third paragraph discussing the architecture decisions that were made during
+		the design phase of this particular system component.</p>
+		<h3>Requirements</h3>
+		<p>A fourth paragraph repeats a heading name so the anchors have to be made unique for
+		every section of the guide.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	options := DefaultArticleOptions()
+	options.IncludeTOC = true
+	article, err := ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+
+	want := []TOCEntry{
+		{Level: 2, Text: "Installation", Slug: "installation", Children: []TOCEntry{
+			{Level: 3, Text: "Requirements", Slug: "requirements"},
+		}},
+		{Level: 2, Text: "Configuration", Slug: "configuration", Children: []TOCEntry{
+			{Level: 3, Text: "Requirements", Slug: "requirements-1"},
+		}},
+	}
+	if !reflect.DeepEqual(article.TOC, want) {
+		t.Errorf("TOC = %+v, want %+v", article.TOC, want)
+	}
+
+	wantTOC := "- [Installation](#installation)\n  - [Requirements](#requirements)\n- [Configuration](#configuration)\n  - [Requirements](#requirements-1)\n"
+	if !strings.HasPrefix(article.Markdown, wantTOC) {
+		t.Errorf("Expected markdown to start with the rendered TOC, got:\n%s", article.Markdown)
+	}
+	for _, want := range []string{
+		"<a id=\"installation\"></a>\n\n## Installation",
+		"<a id=\"requirements-1\"></a>\n\n### Requirements",
+	} {
+		if !strings.Contains(article.Markdown, want) {
+			t.Errorf("Expected markdown to contain %q", want)
+		}
+	}
+	if strings.Contains(article.Markdown, "<a id=\"not-a-heading\"></a>") {
+		t.Error("Expected code blocks to be left alone")
+	}
+	if !strings.Contains(article.html, `id="requirements-1"`) {
+		t.Error("Expected heading ids in the content HTML")
+	}
+}
@@ -0,0 +1,291 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTrackingParams are query parameters added by analytics and ad platforms. A trailing *
+// matches any parameter with that prefix.
+var defaultTrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "gbraid", "wbraid", "msclkid", "yclid", "twclid", "ttclid",
+	"mc_eid", "mc_cid", "igshid", "_hsenc", "_hsmi", "mkt_tok", "li_fat_id", "oly_anon_id",
+	"oly_enc_id", "vero_id", "vero_conv", "wickedid", "s_cid", "ref_src", "_ga", "_gl",
+}
+
+// stripTrackingParams removes query parameters matching rules, keeping the order of the rest
+func stripTrackingParams(rawURL string, rules []string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.RawQuery == "" || len(rules) == 0 {
+		return rawURL
+	}
+
+	params := strings.Split(parsed.RawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key := param
+		if i := strings.Index(key, "="); i >= 0 {
+			key = key[:i]
+		}
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if param == "" || matchesTrackingRule(key, rules) {
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if len(kept) == len(params) {
+		return rawURL
+	}
+
+	parsed.RawQuery = strings.Join(kept, "&")
+	parsed.ForceQuery = false
+	return parsed.String()
+}
+
+// matchesTrackingRule reports whether a query parameter name matches any tracking rule
+func matchesTrackingRule(key string, rules []string) bool {
+	key = strings.ToLower(key)
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if prefix, wildcard := strings.CutSuffix(rule, "*"); wildcard {
+			if prefix != "" && strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// RedirectWrapper describes a redirect or link-tracking URL that carries the destination in a
+// query parameter, e.g. l.facebook.com/l.php?u=...
+type RedirectWrapper struct {
+	// Host matches the wrapper host, ignoring a www. prefix; "google.*" matches any TLD
+	Host string
+	// Path is the wrapper path, or empty to match any path
+	Path string
+	// Params are the query parameters that may hold the destination, in order of preference
+	Params []string
+}
+
+// maxUnwrapDepth limits how many nested wrappers are removed from a single URL
+const maxUnwrapDepth = 5
+
+// shortLinkTimeout bounds how long expanding a short link may take
+const shortLinkTimeout = 10 * time.Second
+
+var (
+	// redirectWrappersMu guards redirectWrappers, which RegisterRedirectWrapper may extend while
+	// requests are being cleaned
+	redirectWrappersMu sync.RWMutex
+
+	// redirectWrappers is the built-in redirect wrapper registry, extended with RegisterRedirectWrapper
+	redirectWrappers = []RedirectWrapper{
+		{Host: "l.facebook.com", Path: "/l.php", Params: []string{"u"}},
+		{Host: "lm.facebook.com", Path: "/l.php", Params: []string{"u"}},
+		{Host: "m.facebook.com", Path: "/l.php", Params: []string{"u"}},
+		{Host: "l.instagram.com", Params: []string{"u"}},
+		{Host: "l.threads.net", Params: []string{"u"}},
+		{Host: "google.*", Path: "/url", Params: []string{"q", "url"}},
+		{Host: "youtube.com", Path: "/redirect", Params: []string{"q"}},
+		{Host: "out.reddit.com", Params: []string{"url"}},
+		{Host: "t.umblr.com", Path: "/redirect", Params: []string{"z"}},
+		{Host: "slack-redir.net", Path: "/link", Params: []string{"url"}},
+		{Host: "safelinks.protection.outlook.com", Params: []string{"url"}},
+		{Host: "linkedin.com", Path: "/redir/redirect", Params: []string{"url"}},
+		{Host: "steamcommunity.com", Path: "/linkfilter/", Params: []string{"url", "u"}},
+		{Host: "href.li"},
+	}
+
+	// shortLinkHosts are URL shorteners that can only be expanded by following their redirect
+	shortLinkHosts = map[string]bool{
+		"t.co": true, "bit.ly": true, "buff.ly": true, "ow.ly": true, "lnkd.in": true, "fb.me": true,
+		"tinyurl.com": true, "goo.gl": true, "dlvr.it": true, "trib.al": true, "is.gd": true,
+	}
+
+	// shortLinkClient follows short link redirects
+	shortLinkClient = &http.Client{Timeout: shortLinkTimeout}
+)
+
+// RegisterRedirectWrapper adds a redirect wrapper to the URL cleaning rules. It is safe to call
+// while URLs are being cleaned.
+func RegisterRedirectWrapper(wrapper RedirectWrapper) {
+	redirectWrappersMu.Lock()
+	defer redirectWrappersMu.Unlock()
+	// Copy on write so readers can keep iterating the slice they loaded
+	redirectWrappers = append(slices.Clip(redirectWrappers), wrapper)
+}
+
+// CleanURL removes redirect wrappers, AMP cache prefixes and tracking parameters from an http(s)
+// URL and normalizes its scheme and host. Other URLs are returned unchanged.
+func CleanURL(rawURL string, trackingParams []string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || !isHTTPURL(parsed) {
+		return rawURL
+	}
+
+	for depth := 0; depth < maxUnwrapDepth; depth++ {
+		unwrapped := unwrapRedirect(parsed)
+		if unwrapped == nil {
+			unwrapped = unwrapAMPCache(parsed)
+		}
+		if unwrapped == nil {
+			break
+		}
+		parsed = unwrapped
+	}
+
+	// Normalize the scheme and host, dropping default ports and empty queries
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	if port := parsed.Port(); (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		parsed.Host = parsed.Hostname()
+	}
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+	parsed.ForceQuery = false
+
+	return stripTrackingParams(parsed.String(), trackingParams)
+}
+
+// unwrapRedirect returns the destination of a known redirect wrapper, or nil
+func unwrapRedirect(parsed *url.URL) *url.URL {
+	redirectWrappersMu.RLock()
+	wrappers := redirectWrappers
+	redirectWrappersMu.RUnlock()
+
+	for _, wrapper := range wrappers {
+		if !matchesWrapperHost(wrapper.Host, parsed.Hostname()) {
+			continue
+		}
+		if wrapper.Path != "" && !strings.EqualFold(parsed.Path, wrapper.Path) {
+			continue
+		}
+
+		candidates := make([]string, 0, len(wrapper.Params)+1)
+		for _, param := range wrapper.Params {
+			candidates = append(candidates, parsed.Query().Get(param))
+		}
+		if len(wrapper.Params) == 0 {
+			// The destination is the whole query string, e.g. href.li/?https://example.com
+			candidates = append(candidates, parsed.RawQuery)
+		}
+
+		for _, candidate := range candidates {
+			if destination, err := url.Parse(strings.TrimSpace(candidate)); err == nil && isHTTPURL(destination) {
+				return destination
+			}
+		}
+	}
+	return nil
+}
+
+// unwrapAMPCache returns the publisher URL behind a Google AMP cache or viewer URL, or nil
+func unwrapAMPCache(parsed *url.URL) *url.URL {
+	host := strings.ToLower(parsed.Hostname())
+
+	var rest string
+	switch {
+	case strings.HasSuffix(host, ".cdn.ampproject.org"):
+		// /c/s/example.com/path: c serves documents, i images, v viewer; s marks https
+		parts := strings.SplitN(strings.TrimPrefix(parsed.Path, "/"), "/", 2)
+		if len(parts) != 2 || (parts[0] != "c" && parts[0] != "v" && parts[0] != "i") {
+			return nil
+		}
+		rest = parts[1]
+	case matchesWrapperHost("google.*", host) && strings.HasPrefix(parsed.Path, "/amp/"):
+		rest = strings.TrimPrefix(parsed.Path, "/amp/")
+	default:
+		return nil
+	}
+
+	scheme := "http"
+	if strings.HasPrefix(rest, "s/") {
+		scheme = "https"
+		rest = strings.TrimPrefix(rest, "s/")
+	}
+	destination, err := url.Parse(scheme + "://" + rest)
+	if err != nil || destination.Host == "" {
+		return nil
+	}
+	destination.RawQuery = parsed.RawQuery
+	destination.Fragment = parsed.Fragment
+	return destination
+}
+
+// matchesWrapperHost reports whether host matches a wrapper host pattern, ignoring a www. prefix
+func matchesWrapperHost(pattern, host string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	pattern = strings.ToLower(pattern)
+	if prefix, wildcard := strings.CutSuffix(pattern, "*"); wildcard {
+		return strings.HasPrefix(host, prefix)
+	}
+	return host == pattern
+}
+
+// isHTTPURL reports whether a parsed URL is an absolute http(s) URL
+func isHTTPURL(parsed *url.URL) bool {
+	return (strings.EqualFold(parsed.Scheme, "http") || strings.EqualFold(parsed.Scheme, "https")) && parsed.Host != ""
+}
+
+// expandShortLink follows the redirect of a known URL shortener and returns the destination
+func (ac *ArticleCleaner) expandShortLink(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || !shortLinkHosts[strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")] {
+		return rawURL, err
+	}
+
+	req, err := http.NewRequest("HEAD", rawURL, nil)
+	if err != nil {
+		return rawURL, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := shortLinkClient.Do(req)
+	if err != nil {
+		return rawURL, err
+	}
+	resp.Body.Close()
+
+	ac.logger.Debugw("Expanded short link", "url", rawURL, "destination", resp.Request.URL.String())
+	return resp.Request.URL.String(), nil
+}
+
+// GetCleanURL cleans a URL, first expanding known short links when expandShortLinks is set
+func GetCleanURL(rawURL string, trackingParams []string, expandShortLinks bool) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+	if !isHTTPURL(parsed) {
+		return "", fmt.Errorf("not an absolute http(s) URL: %q", rawURL)
+	}
+
+	cleaned := CleanURL(rawURL, trackingParams)
+	if !expandShortLinks {
+		return cleaned, nil
+	}
+
+	cleaner, err := NewArticleCleaner()
+	if err != nil {
+		return "", err
+	}
+	defer cleaner.Close()
+
+	expanded, err := cleaner.expandShortLink(cleaned)
+	if err != nil {
+		cleaner.logger.Warnw("Failed to expand short link", "url", cleaned, "error", err)
+		return cleaned, nil
+	}
+	return CleanURL(expanded, trackingParams), nil
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestCleanURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"tracking parameters", "https://example.com/post?utm_source=twitter&utm_medium=social&id=4&fbclid=abc", "https://example.com/post?id=4"},
+		{"mailchimp parameters", "https://example.com/post?mc_cid=1&mc_eid=2", "https://example.com/post"},
+		{"facebook wrapper", "https://l.facebook.com/l.php?u=https%3A%2F%2Fexample.com%2Fpost%3Futm_source%3Dfb&h=AT0", "https://example.com/post"},
+		{"google redirect", "https://www.google.com/url?sa=t&url=https%3A%2F%2Fexample.com%2Fa&q=https%3A%2F%2Fexample.com%2Fb", "https://example.com/b"},
+		{"google redirect on other TLD", "https://www.google.co.uk/url?q=https://example.com/a&sa=D", "https://example.com/a"},
+		{"nested wrappers", "https://www.google.com/url?q=" + url.QueryEscape("https://l.facebook.com/l.php?u="+url.QueryEscape("https://example.com/deep?gclid=1")), "https://example.com/deep"},
+		{"AMP cache https", "https://www-example-com.cdn.ampproject.org/c/s/www.example.com/amp/story.html?utm_campaign=x", "https://www.example.com/amp/story.html"},
+		{"AMP cache http", "https://example-org.cdn.ampproject.org/v/example.org/news", "http://example.org/news"},
+		{"google AMP viewer", "https://www.google.com/amp/s/example.com/2024/01/story/", "https://example.com/2024/01/story/"},
+		{"query-only wrapper", "https://href.li/?https://example.com/page", "https://example.com/page"},
+		{"wrapper without destination kept", "https://l.facebook.com/l.php?h=AT0", "https://l.facebook.com/l.php?h=AT0"},
+		{"non-http destination ignored", "https://www.google.com/url?q=javascript:alert(1)", "https://www.google.com/url?q=javascript:alert(1)"},
+		{"host case and default port", "HTTPS://Example.COM:443/Path?b=1", "https://example.com/Path?b=1"},
+		{"empty path", "https://example.com", "https://example.com/"},
+		{"fragment kept", "https://example.com/a?utm_term=x#section", "https://example.com/a#section"},
+		{"non-http untouched", "mailto:team@example.com", "mailto:team@example.com"},
+		{"google search page untouched", "https://www.google.com/search?q=page+zen", "https://www.google.com/search?q=page+zen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanURL(tt.url, defaultTrackingParams); got != tt.want {
+				t.Errorf("CleanURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterRedirectWrapper(t *testing.T) {
+	original := redirectWrappers
+	defer func() { redirectWrappers = original }()
+
+	wrapped := "https://links.example.net/click?target=https%3A%2F%2Fexample.com%2Fa"
+	if got := CleanURL(wrapped, nil); got != wrapped {
+		t.Fatalf("Expected unknown wrapper to be kept, got %q", got)
+	}
+
+	RegisterRedirectWrapper(RedirectWrapper{Host: "links.example.net", Path: "/click", Params: []string{"target"}})
+	if got := CleanURL(wrapped, nil); got != "https://example.com/a" {
+		t.Errorf("Expected registered wrapper to be removed, got %q", got)
+	}
+}
+
+func TestRegisterRedirectWrapperConcurrently(t *testing.T) {
+	original := redirectWrappers
+	defer func() { redirectWrappers = original }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			RegisterRedirectWrapper(RedirectWrapper{Host: fmt.Sprintf("links%d.example.net", i), Params: []string{"url"}})
+		}(i)
+		go func() {
+			defer wg.Done()
+			CleanURL("https://l.facebook.com/l.php?u=https%3A%2F%2Fexample.com%2F", nil)
+		}()
+	}
+	wg.Wait()
+
+	if got := len(redirectWrappers) - len(original); got != 8 {
+		t.Errorf("Expected 8 registered wrappers, got %d", got)
+	}
+}
+
+func TestExpandShortLink(t *testing.T) {
+	destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer destination.Close()
+
+	shortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, destination.URL+"/article?utm_source=twitter", http.StatusMovedPermanently)
+	}))
+	defer shortener.Close()
+
+	shortURL, _ := url.Parse(shortener.URL)
+	shortLinkHosts[shortURL.Hostname()] = true
+	defer delete(shortLinkHosts, shortURL.Hostname())
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	expanded, err := ac.expandShortLink(shortener.URL + "/abc123")
+	if err != nil {
+		t.Fatalf("expandShortLink failed: %v", err)
+	}
+	if got := CleanURL(expanded, defaultTrackingParams); got != destination.URL+"/article" {
+		t.Errorf("Expected expanded and cleaned URL %s/article, got %s", destination.URL, got)
+	}
+}
+
+func TestCleanArticleCleansOpenGraphURLWithRequestRules(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html>
+<head>
+	<title>Tracked</title>
+	<meta property="og:url" content="https://example.com/article?id=7&amp;src=newsletter&amp;utm_source=feed" />
+</head>
+<body>
+	<article>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the content length threshold that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<p>Here is a second paragraph with more meaningful content about distributed systems
+		and how they handle failure modes in production environments.</p>
+	</article>
+</body>
+</html>`))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	options := DefaultArticleOptions()
+	options.TrackingParams = append(options.TrackingParams, "src")
+	article, err := ac.CleanArticleWithOptions(ts.URL, options)
+	if err != nil {
+		t.Fatalf("CleanArticleWithOptions failed: %v", err)
+	}
+
+	if want := "https://example.com/article?id=7"; article.OpenGraph.URL != want {
+		t.Errorf("Expected og:url %s cleaned with the request rules, got %s", want, article.OpenGraph.URL)
+	}
+}
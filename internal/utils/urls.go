@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// urlAttributes lists the URL-bearing attributes resolved in article content, by element
+var urlAttributes = []struct {
+	selector string
+	attr     string
+}{
+	{"a", "href"},
+	{"area", "href"},
+	{"img", "src"},
+	{"source", "src"},
+	{"video", "src"},
+	{"video", "poster"},
+	{"audio", "src"},
+	{"track", "src"},
+	{"iframe", "src"},
+	{"embed", "src"},
+	{"object", "data"},
+	{"blockquote", "cite"},
+	{"q", "cite"},
+	{"ins", "cite"},
+	{"del", "cite"},
+}
+
+// documentBaseURL returns the base URL used to resolve relative URLs in the document: the first
+// <base href>, itself resolved against the page URL, or the page URL when there is none
+func documentBaseURL(doc *goquery.Document, pageURL *url.URL) *url.URL {
+	href, exists := doc.Find("base[href]").First().Attr("href")
+	if !exists || pageURL == nil {
+		return pageURL
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return pageURL
+	}
+	base := pageURL.ResolveReference(ref)
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return pageURL
+	}
+	return base
+}
+
+// resolveDocumentURLs makes every URL-bearing attribute in the document absolute
+func (ac *ArticleCleaner) resolveDocumentURLs(doc *goquery.Document, baseURL *url.URL) {
+	resolvedCount := 0
+	for _, target := range urlAttributes {
+		doc.Find(target.selector + "[" + target.attr + "]").Each(func(i int, s *goquery.Selection) {
+			value := s.AttrOr(target.attr, "")
+			if resolved := ac.resolveURL(value, baseURL); resolved != value {
+				s.SetAttr(target.attr, resolved)
+				resolvedCount++
+			}
+		})
+	}
+
+	// Responsive sources left outside <picture>, e.g. inside <video>
+	doc.Find("[srcset]").Each(func(i int, s *goquery.Selection) {
+		candidates := parseSrcset(s.AttrOr("srcset", ""))
+		if len(candidates) == 0 {
+			return
+		}
+		parts := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			part := ac.resolveURL(candidate.URL, baseURL)
+			if candidate.Width > 0 {
+				part += " " + strconv.Itoa(candidate.Width) + "w"
+			} else if candidate.Density != 1 {
+				part += " " + strconv.FormatFloat(candidate.Density, 'f', -1, 64) + "x"
+			}
+			parts = append(parts, part)
+		}
+		s.SetAttr("srcset", strings.Join(parts, ", "))
+		resolvedCount++
+	})
+
+	if resolvedCount > 0 {
+		ac.logger.Debugw("Resolved relative URLs", "count", resolvedCount)
+	}
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestResolveURL(t *testing.T) {
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	base, _ := url.Parse("https://example.com/blog/post/index.html?page=2#comments")
+
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{"empty", "", ""},
+		{"whitespace only", "   ", ""},
+		{"absolute http", "http://other.example.org/a.png", "http://other.example.org/a.png"},
+		{"absolute https kept verbatim", "https://cdn.example.com/a b.png", "https://cdn.example.com/a b.png"},
+		{"protocol-relative keeps base scheme", "//cdn.example.com/img.png", "https://cdn.example.com/img.png"},
+		{"root-relative", "/images/a.png", "https://example.com/images/a.png"},
+		{"document-relative", "img.png", "https://example.com/blog/post/img.png"},
+		{"dot segment", "./img.png", "https://example.com/blog/post/img.png"},
+		{"parent directory", "../img.png", "https://example.com/blog/img.png"},
+		{"too many parents", "../../../../img.png", "https://example.com/img.png"},
+		{"query only", "?page=3", "https://example.com/blog/post/index.html?page=3"},
+		{"fragment only", "#section-2", "https://example.com/blog/post/index.html?page=2#section-2"},
+		{"surrounding whitespace", "  img.png\n", "https://example.com/blog/post/img.png"},
+		{"mailto untouched", "mailto:editor@example.com", "mailto:editor@example.com"},
+		{"data URI untouched", "data:image/gif;base64,R0lGODlhAQABAAAAACw=", "data:image/gif;base64,R0lGODlhAQABAAAAACw="},
+		{"javascript untouched", "javascript:void(0)", "javascript:void(0)"},
+		{"encoded characters preserved", "caf%C3%A9.png", "https://example.com/blog/post/caf%C3%A9.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ac.resolveURL(tt.ref, base); got != tt.want {
+				t.Errorf("resolveURL(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentBaseURL(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/blog/post/")
+
+	tests := []struct {
+		name string
+		head string
+		want string
+	}{
+		{"no base element", "", "https://example.com/blog/post/"},
+		{"absolute base", `<base href="https://static.example.net/articles/42/">`, "https://static.example.net/articles/42/"},
+		{"relative base", `<base href="../assets/">`, "https://example.com/blog/assets/"},
+		{"target-only base", `<base target="_blank">`, "https://example.com/blog/post/"},
+		{"non-http base ignored", `<base href="javascript:alert(1)">`, "https://example.com/blog/post/"},
+		{"first base wins", `<base href="/first/"><base href="/second/">`, "https://example.com/first/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><head>" + tt.head + "</head><body></body></html>"))
+			if err != nil {
+				t.Fatalf("Failed to parse document: %v", err)
+			}
+			if got := documentBaseURL(doc, pageURL).String(); got != tt.want {
+				t.Errorf("documentBaseURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanArticleResolvesAllURLs(t *testing.T) {
+	articleHTML := `<html>
+<head>
+	<title>Relative URLs</title>
+	<base href="/blog/2024/">
+</head>
+<body>
+	<article>
+		<h1>Relative URLs</h1>
+		<p>This is a substantial test article with enough content to be extracted by readability.
+		It needs multiple paragraphs to pass the <a href="notes.html">content length threshold</a> that readability uses
+		to determine if something is actual article content or just noise.</p>
+		<p><img src="figures/chart.png" alt="Chart"></p>
+		<p>Here is a second paragraph with more meaningful content about <a href="../2023/systems.html">distributed systems</a>
+		and how they handle failure modes in production environments, see <a href="?ref=footer">the footer</a>.</p>
+		<video poster="media/poster.jpg" controls>
+			<source src="media/clip.mp4" type="video/mp4">
+		</video>
+		<audio src="media/episode.mp3" controls></audio>
+		<p>And a third paragraph discussing the architecture decisions that were made during
+		the design phase of this particular <a href="//docs.example.org/component">system component</a>.</p>
+	</article>
+</body>
+</html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articleHTML))
+	}))
+	defer ts.Close()
+
+	ac, err := NewArticleCleaner()
+	if err != nil {
+		t.Fatalf("Failed to create ArticleCleaner: %v", err)
+	}
+	defer ac.Close()
+
+	article, err := ac.CleanArticle(ts.URL + "/posts/relative-urls")
+	if err != nil {
+		t.Fatalf("CleanArticle failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"(" + ts.URL + "/blog/2024/notes.html)",
+		"(" + ts.URL + "/blog/2024/figures/chart.png)",
+		"(" + ts.URL + "/blog/2023/systems.html)",
+		"(" + ts.URL + "/blog/2024/?ref=footer)",
+		"(http://docs.example.org/component)",
+	} {
+		if !strings.Contains(article.Markdown, want) {
+			t.Errorf("Expected markdown to contain %s\n%s", want, article.Markdown)
+		}
+	}
+
+	for _, want := range []string{
+		`poster="` + ts.URL + `/blog/2024/media/poster.jpg"`,
+		`src="` + ts.URL + `/blog/2024/media/clip.mp4"`,
+		`src="` + ts.URL + `/blog/2024/media/episode.mp3"`,
+	} {
+		if !strings.Contains(article.html, want) {
+			t.Errorf("Expected content HTML to contain %s", want)
+		}
+	}
+}